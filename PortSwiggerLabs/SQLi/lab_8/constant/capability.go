@@ -0,0 +1,55 @@
+package constant
+
+import "fmt"
+
+// Capability is an optional dialect feature that some techniques depend on.
+type Capability string
+
+const (
+	CAPABILITY_AGGREGATION       Capability = "aggregation"
+	CAPABILITY_BLIND             Capability = "blind retrieval"
+	CAPABILITY_TIME_DELAY        Capability = "time delay"
+	CAPABILITY_CONDITIONAL_ERROR Capability = "conditional error"
+	CAPABILITY_ERROR_LEAK        Capability = "error leak"
+	CAPABILITY_STACKED_QUERIES   Capability = "stacked queries"
+	CAPABILITY_OOB               Capability = "out-of-band"
+)
+
+var Capabilities = []Capability{
+	CAPABILITY_AGGREGATION,
+	CAPABILITY_BLIND,
+	CAPABILITY_TIME_DELAY,
+	CAPABILITY_CONDITIONAL_ERROR,
+	CAPABILITY_ERROR_LEAK,
+	CAPABILITY_STACKED_QUERIES,
+	CAPABILITY_OOB,
+}
+
+// Supports reports whether the descriptor has everything the capability needs.
+func (db Database) Supports(capability Capability) bool {
+	switch capability {
+	case CAPABILITY_AGGREGATION:
+		return db.AggregateFunction != ""
+	case CAPABILITY_BLIND:
+		return db.SubstringFunction != "" && db.LengthFunction != "" && db.CodePointFunction != ""
+	case CAPABILITY_TIME_DELAY:
+		return db.TimeDelayPayload != ""
+	case CAPABILITY_CONDITIONAL_ERROR:
+		return db.ConditionalError != ""
+	case CAPABILITY_ERROR_LEAK:
+		return db.ErrorPayload != "" && db.ErrorRegex != ""
+	case CAPABILITY_STACKED_QUERIES:
+		return db.StackedQueries && db.SleepStatement != ""
+	case CAPABILITY_OOB:
+		return db.OOBPayload != "" && db.HexFunction != ""
+	}
+	return false
+}
+
+// Require returns an error naming the capability when the database does not support it.
+func (db Database) Require(capability Capability) error {
+	if !db.Supports(capability) {
+		return fmt.Errorf("the database %s does not support %s", db.Name, capability)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package constant
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSupports(t *testing.T) {
+	tests := []struct {
+		db      Database
+		missing []Capability
+	}{
+		{ORACLE, []Capability{CAPABILITY_STACKED_QUERIES}},
+		{MSSQL, nil},
+		{MYSQL, nil},
+		{POSTGRESQL, nil},
+		{SQLITE, []Capability{CAPABILITY_ERROR_LEAK, CAPABILITY_STACKED_QUERIES, CAPABILITY_OOB}},
+		{Database{Name: "Empty"}, Capabilities},
+	}
+	for _, test := range tests {
+		t.Run(test.db.Name, func(t *testing.T) {
+			for _, capability := range Capabilities {
+				want := !slices.Contains(test.missing, capability)
+				if got := test.db.Supports(capability); got != want {
+					t.Errorf("Supports(%s) = %t, want %t", capability, got, want)
+				}
+				err := test.db.Require(capability)
+				if (err == nil) != want {
+					t.Errorf("Require(%s) = %v", capability, err)
+				}
+				if err != nil && !strings.Contains(err.Error(), string(capability)) {
+					t.Errorf("error %q does not name the capability", err)
+				}
+			}
+		})
+	}
+}
+
+func TestSupportsNeedsEveryField(t *testing.T) {
+	tests := []struct {
+		name       string
+		capability Capability
+		strip      func(db *Database)
+	}{
+		{"blind without a code point function", CAPABILITY_BLIND, func(db *Database) { db.CodePointFunction = "" }},
+		{"error leak without a regex", CAPABILITY_ERROR_LEAK, func(db *Database) { db.ErrorRegex = "" }},
+		{"stacked queries without a sleep", CAPABILITY_STACKED_QUERIES, func(db *Database) { db.SleepStatement = "" }},
+		{"stacked queries not chained", CAPABILITY_STACKED_QUERIES, func(db *Database) { db.StackedQueries = false }},
+		{"out-of-band without hex", CAPABILITY_OOB, func(db *Database) { db.HexFunction = "" }},
+		{"unknown capability", Capability("teleport"), func(db *Database) {}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := POSTGRESQL
+			test.strip(&db)
+			if db.Supports(test.capability) {
+				t.Errorf("%s is still supported", test.capability)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package constant
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Catalog names the system views that describe schemas, tables and columns.
+type Catalog struct {
+	TablesView   string // Lists one row per table
+	ColumnsView  string // Lists one row per column
+	SchemaColumn string // Schema (owner) name column in both views
+	TableColumn  string // Table name column in both views
+	ColumnColumn string // Column name column in ColumnsView
+	TypeColumn   string // Data type column in ColumnsView
+}
+
+var (
+	INFORMATION_SCHEMA_CATALOG = Catalog{
+		TablesView:   "information_schema.tables",
+		ColumnsView:  "information_schema.columns",
+		SchemaColumn: "table_schema",
+		TableColumn:  "table_name",
+		ColumnColumn: "column_name",
+		TypeColumn:   "data_type",
+	}
+	// SQLITE_CATALOG renames sqlite_master and pragma_table_info to information_schema's
+	// columns, so catalog queries look the same as on the other databases
+	SQLITE_CATALOG = Catalog{
+		TablesView:   "(SELECT 'main' AS table_schema,name AS table_name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite%') qzx_t",
+		ColumnsView:  "(SELECT 'main' AS table_schema,m.name AS table_name,p.name AS column_name,p.type AS data_type FROM sqlite_master m JOIN pragma_table_info(m.name) p WHERE m.type='table') qzx_c",
+		SchemaColumn: "table_schema",
+		TableColumn:  "table_name",
+		ColumnColumn: "column_name",
+		TypeColumn:   "data_type",
+	}
+	ORACLE_CATALOG = Catalog{
+		TablesView:   "all_tables",
+		ColumnsView:  "all_tab_columns",
+		SchemaColumn: "owner",
+		TableColumn:  "table_name",
+		ColumnColumn: "column_name",
+		TypeColumn:   "data_type",
+	}
+)
+
+// PagingStyle is how a database selects the nth row of a result set.
+type PagingStyle int
+
+const (
+	PAGING_LIMIT_OFFSET PagingStyle = iota // ... LIMIT 1 OFFSET n
+	PAGING_OFFSET_FETCH                    // ... ORDER BY 1 OFFSET n ROWS FETCH NEXT 1 ROWS ONLY
+	PAGING_ROWNUM                          // Nested SELECT filtered on ROWNUM
+)
+
+// From returns the FROM clause for the table, falling back to the dual
+// table on databases that cannot SELECT without one.
+func (db Database) From(table string) string {
+	if table == "" {
+		table = db.DualTable
+	}
+	if table == "" {
+		return ""
+	}
+	return " FROM " + table
+}
+
+// TablesQuery returns the catalog view and condition that list the tables of a schema,
+// or of every schema when schema is empty.
+func (db Database) TablesQuery(schema string) (string, string) {
+	return db.Catalog.TablesView, db.schemaCondition(schema)
+}
+
+// ColumnsQuery returns the catalog view and condition that list the columns of a table.
+func (db Database) ColumnsQuery(schema string, table string) (string, string) {
+	condition := fmt.Sprintf("%s=%s", db.Catalog.TableColumn, db.Quote(table))
+	if schemaCondition := db.schemaCondition(schema); schemaCondition != "" {
+		condition += " AND " + schemaCondition
+	}
+	return db.Catalog.ColumnsView, condition
+}
+
+func (db Database) schemaCondition(schema string) string {
+	if schema == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s=%s", db.Catalog.SchemaColumn, db.Quote(schema))
+}
+
+// Quote returns the value as a single-quoted SQL string literal.
+func Quote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// Quote returns the value as a string literal of the dialect, escaping backslashes where they are special.
+func (db Database) Quote(value string) string {
+	if db.BackslashEscapes {
+		value = strings.ReplaceAll(value, `\`, `\\`)
+	}
+	return Quote(value)
+}
+
+// Concat joins SQL expressions with the database's concatenation operator or function.
+func (db Database) Concat(parts ...string) string {
+	if db.ConcatFunction != "" {
+		return db.ConcatFunction + "(" + strings.Join(parts, ",") + ")"
+	}
+	return strings.Join(parts, db.Concatenation)
+}
+
+// CastToString converts an expression to a string so it can be compared and reflected.
+func (db Database) CastToString(expression string) string {
+	return fmt.Sprintf(db.CastFunction, expression)
+}
+
+// Hex hex-encodes an expression cast to a string, so values of every string type encode the same way.
+func (db Database) Hex(expression string) string {
+	return fmt.Sprintf(db.HexFunction, db.CastToString(expression))
+}
+
+// SystemSchemaCondition excludes the built-in schemas from catalog queries.
+func (db Database) SystemSchemaCondition() string {
+	if len(db.SystemSchemas) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(db.SystemSchemas))
+	for i, schema := range db.SystemSchemas {
+		quoted[i] = db.Quote(schema)
+	}
+	return fmt.Sprintf("%s NOT IN (%s)", db.Catalog.SchemaColumn, strings.Join(quoted, ","))
+}
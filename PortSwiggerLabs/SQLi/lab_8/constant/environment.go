@@ -0,0 +1,79 @@
+package constant
+
+// Environment names the expressions and system views that describe the database server
+// and its accounts. Empty fields are facts the dialect cannot reveal.
+type Environment struct {
+	CurrentUser     string // Account the application connects with
+	CurrentDatabase string // Database the vulnerable query runs in
+	Hostname        string // Name or address of the database server
+	// DBACondition is true when the current user is a superuser or has the DBA role
+	DBACondition string
+
+	UsersView  string // Lists the database accounts, possibly several rows per account
+	UserColumn string // Account name in UsersView, as an expression of its columns
+	// UsersFallbackView is listed instead when UsersView cannot be read
+	UsersFallbackView   string
+	UsersFallbackColumn string // Account name column in UsersFallbackView
+
+	// PasswordsView lists the password hash of each account, readable by administrators only
+	PasswordsView      string
+	PasswordUserColumn string // Account name in PasswordsView
+	PasswordHashColumn string // Password hash in PasswordsView, as an expression of its columns
+}
+
+var (
+	ORACLE_ENVIRONMENT = Environment{
+		CurrentUser:        "USER",
+		CurrentDatabase:    "SYS_CONTEXT('USERENV','DB_NAME')",
+		Hostname:           "SYS_CONTEXT('USERENV','SERVER_HOST')",
+		DBACondition:       "(SELECT COUNT(*) FROM session_roles WHERE role='DBA')>0",
+		UsersView:          "all_users",
+		UserColumn:         "username",
+		PasswordsView:      "sys.user$",
+		PasswordUserColumn: "name",
+		PasswordHashColumn: "COALESCE(spare4,password)", // spare4 holds the SHA-1 and 12c hashes, password the DES one
+	}
+	MSSQL_ENVIRONMENT = Environment{
+		CurrentUser:        "SYSTEM_USER",
+		CurrentDatabase:    "DB_NAME()",
+		Hostname:           "@@SERVERNAME",
+		DBACondition:       "IS_SRVROLEMEMBER('sysadmin')=1",
+		UsersView:          "sys.syslogins",
+		UserColumn:         "name",
+		PasswordsView:      "sys.sql_logins",
+		PasswordUserColumn: "name",
+		PasswordHashColumn: "CONVERT(varchar(max),password_hash,1)",
+	}
+	// MYSQL_ENVIRONMENT has no DBA role, so a user holding SUPER, or the dynamic privileges that
+	// replace it since 8.0, is reported as the DBA. Reading mysql.user needs a grant on the mysql
+	// schema, without one the accounts come from user_privileges, quoted as 'user'@'host'.
+	MYSQL_ENVIRONMENT = Environment{
+		CurrentUser:         "CURRENT_USER()",
+		CurrentDatabase:     "DATABASE()",
+		Hostname:            "@@hostname",
+		DBACondition:        "(SELECT COUNT(*) FROM information_schema.user_privileges WHERE privilege_type IN ('SUPER','SYSTEM_USER','SYSTEM_VARIABLES_ADMIN') AND grantee=CONCAT('''',REPLACE(CURRENT_USER(),'@','''@'''),''''))>0",
+		UsersView:           "mysql.user",
+		UserColumn:          "CONCAT(user,'@',host)",
+		UsersFallbackView:   "information_schema.user_privileges",
+		UsersFallbackColumn: "grantee",
+		PasswordsView:       "mysql.user",
+		PasswordUserColumn:  "CONCAT(user,'@',host)",
+		PasswordHashColumn:  "authentication_string",
+	}
+	// POSTGRESQL_ENVIRONMENT has no function returning the server's host name, so its address is used
+	POSTGRESQL_ENVIRONMENT = Environment{
+		CurrentUser:        "CURRENT_USER",
+		CurrentDatabase:    "current_database()",
+		Hostname:           "host(inet_server_addr())",
+		DBACondition:       "(SELECT usesuper FROM pg_user WHERE usename=CURRENT_USER)",
+		UsersView:          "pg_user",
+		UserColumn:         "usename",
+		PasswordsView:      "pg_shadow",
+		PasswordUserColumn: "usename",
+		PasswordHashColumn: "passwd",
+	}
+	// SQLITE_ENVIRONMENT is a file without accounts or a server, so only the database file is known
+	SQLITE_ENVIRONMENT = Environment{
+		CurrentDatabase: "(SELECT file FROM pragma_database_list WHERE name='main')",
+	}
+)
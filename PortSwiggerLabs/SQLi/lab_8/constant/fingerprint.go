@@ -0,0 +1,120 @@
+package constant
+
+import "regexp"
+
+// FingerprintCheck is one behavioural probe that a product is expected to pass.
+// Value checks select Expression through a UNION SELECT and compare what the page
+// renders; comment checks test whether a comment style terminates the query.
+type FingerprintCheck struct {
+	Description string
+	Expression  string // Selected in a UNION column, wrapped in markers
+	Table       string // Table the expression is selected from, empty for none
+	Expect      string // Value the expression must render, empty for any value
+	Fails       bool   // The check passes when the query is rejected instead
+	Comment     string // When set, the check tests this comment style instead of an expression
+	Accepted    bool   // Whether the comment style is expected to terminate the query
+}
+
+// Product is a DBMS that fingerprinting can recognise. Several products can share
+// a dialect, e.g. MariaDB and MySQL or CockroachDB and PostgreSQL.
+type Product struct {
+	Name           string
+	Database       Database
+	Checks         []FingerprintCheck
+	ErrorSignature *regexp.Regexp // Matches the product's error messages
+}
+
+// FINGERPRINT_MIN_CONFIDENCE is the share of checks the best product must pass.
+const FINGERPRINT_MIN_CONFIDENCE = 0.5
+
+// VERSION_PATTERN captures the version number from a version banner.
+const VERSION_PATTERN = `\d+(?:\.\d+)+`
+
+var Products = []Product{
+	{
+		Name:     "Oracle",
+		Database: ORACLE,
+		Checks: []FingerprintCheck{
+			{Description: "|| concatenates strings", Expression: "'qz'||'x'", Table: "dual", Expect: "qzx"},
+			{Description: "SELECT requires a FROM clause", Expression: "'x'", Fails: true},
+			{Description: "BITAND is available", Expression: "TO_CHAR(BITAND(5,3))", Table: "dual", Expect: "1"},
+			{Description: "v$version is readable", Expression: "banner", Table: "v$version"},
+			{Description: "# is not a comment", Comment: HASH_COMMENT, Accepted: false},
+		},
+		ErrorSignature: regexp.MustCompile(`\bORA-\d{5}|Oracle error|quoted string not properly terminated`),
+	},
+	{
+		Name:     "MSSQL",
+		Database: MSSQL,
+		Checks: []FingerprintCheck{
+			{Description: "+ concatenates strings", Expression: "'qz'+'x'", Expect: "qzx"},
+			{Description: "LEN is available", Expression: "CONVERT(varchar,LEN('abc'))", Expect: "3"},
+			{Description: "@@SPID is available", Expression: "CONVERT(varchar,@@SPID)"},
+			{Description: "DB_NAME is available", Expression: "DB_NAME()"},
+			{Description: "# is not a comment", Comment: HASH_COMMENT, Accepted: false},
+		},
+		ErrorSignature: regexp.MustCompile(`Unclosed quotation mark after the character string|Microsoft SQL Native Client|ODBC SQL Server Driver|SqlException|Incorrect syntax near`),
+	},
+	{
+		Name:     "MySQL",
+		Database: MYSQL,
+		Checks: []FingerprintCheck{
+			{Description: "|| is a logical OR", Expression: "CAST('qz'||'x' AS char)", Expect: "0"},
+			{Description: "CONNECTION_ID is available", Expression: "CAST(CONNECTION_ID() AS char)"},
+			{Description: "@@version_comment is available", Expression: "@@version_comment"},
+			{Description: "version is not MariaDB", Expression: "CAST(VERSION() LIKE '%MariaDB%' AS char)", Expect: "0"},
+			{Description: "# is a comment", Comment: HASH_COMMENT, Accepted: true},
+			{Description: "-- needs a trailing space", Comment: DOUBLE_DASH_COMMENT, Accepted: false},
+		},
+		ErrorSignature: regexp.MustCompile(`(?i)SQL syntax.*MySQL|MySqlException|valid MySQL result|check the manual that (?:corresponds|fits) to your MySQL server version`),
+	},
+	{
+		Name:     "MariaDB",
+		Database: MYSQL,
+		Checks: []FingerprintCheck{
+			{Description: "|| is a logical OR", Expression: "CAST('qz'||'x' AS char)", Expect: "0"},
+			{Description: "CONNECTION_ID is available", Expression: "CAST(CONNECTION_ID() AS char)"},
+			{Description: "@@version_comment is available", Expression: "@@version_comment"},
+			{Description: "version is MariaDB", Expression: "CAST(VERSION() LIKE '%MariaDB%' AS char)", Expect: "1"},
+			{Description: "# is a comment", Comment: HASH_COMMENT, Accepted: true},
+			{Description: "-- needs a trailing space", Comment: DOUBLE_DASH_COMMENT, Accepted: false},
+		},
+		ErrorSignature: regexp.MustCompile(`(?i)MariaDB server version`),
+	},
+	{
+		Name:     "PostgreSQL",
+		Database: POSTGRESQL,
+		Checks: []FingerprintCheck{
+			{Description: "|| concatenates strings", Expression: "'qz'||'x'", Expect: "qzx"},
+			{Description: "pg_backend_pid is available", Expression: "CAST(pg_backend_pid() AS text)"},
+			{Description: "server_version setting is readable", Expression: "current_setting('server_version')"},
+			{Description: "version is not CockroachDB", Expression: "CAST(strpos(version(),'CockroachDB')=0 AS text)", Expect: "true"},
+			{Description: "# is not a comment", Comment: HASH_COMMENT, Accepted: false},
+		},
+		ErrorSignature: regexp.MustCompile(`(?i)PostgreSQL.*?ERROR|pg_query\(\)|PSQLException|syntax error at or near|unterminated quoted string at or near`),
+	},
+	{
+		Name:     "CockroachDB",
+		Database: POSTGRESQL,
+		Checks: []FingerprintCheck{
+			{Description: "|| concatenates strings", Expression: "'qz'||'x'", Expect: "qzx"},
+			{Description: "unique_rowid is available", Expression: "CAST(unique_rowid() AS text)"},
+			{Description: "server_version setting is readable", Expression: "current_setting('server_version')"},
+			{Description: "version is CockroachDB", Expression: "CAST(strpos(version(),'CockroachDB')>0 AS text)", Expect: "true"},
+			{Description: "# is not a comment", Comment: HASH_COMMENT, Accepted: false},
+		},
+		ErrorSignature: regexp.MustCompile(`(?i)cockroach`),
+	},
+	{
+		Name:     "SQLite",
+		Database: SQLITE,
+		Checks: []FingerprintCheck{
+			{Description: "|| concatenates strings", Expression: "'qz'||'x'", Expect: "qzx"},
+			{Description: "sqlite_version is available", Expression: "sqlite_version()"},
+			{Description: "typeof is available", Expression: "typeof(1)", Expect: "integer"},
+			{Description: "randomblob is available", Expression: "hex(randomblob(1))"},
+			{Description: "# is not a comment", Comment: HASH_COMMENT, Accepted: false},
+		},
+		ErrorSignature: regexp.MustCompile(`(?i)SQLite(?:3|\.Exception| error)|SQLITE_ERROR|unrecognized token:`),
+	},
+}
@@ -0,0 +1,10 @@
+module github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8
+
+go 1.23.0
+
+require github.com/PuerkitoBio/goquery v1.10.3
+
+require (
+	github.com/andybalholm/cascadia v1.3.3 // indirect
+	golang.org/x/net v0.39.0 // indirect
+)
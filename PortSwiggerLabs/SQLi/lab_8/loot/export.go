@@ -0,0 +1,154 @@
+package loot
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/sqli"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/utility"
+)
+
+// columnsTable describes every mirrored column in the SQL export, as the catalog did on the target.
+const columnsTable = "loot_columns"
+
+// affinities map words found in the data types reported by the target to the SQLite type
+// their columns are created with, following SQLite's own affinity rules. The first match wins.
+var affinities = []struct {
+	pattern *regexp.Regexp
+	sqlite  string
+}{
+	{regexp.MustCompile(`(?i)INT`), "INTEGER"},
+	{regexp.MustCompile(`(?i)CHAR|CLOB|TEXT|STRING|UUID|JSON|XML`), "TEXT"},
+	{regexp.MustCompile(`(?i)BLOB|BINARY|BYTEA|RAW|IMAGE`), "BLOB"},
+	{regexp.MustCompile(`(?i)REAL|FLOA|DOUB`), "REAL"},
+	{regexp.MustCompile(`(?i)NUM|DEC|MONEY|BOOL|BIT|DATE|TIME`), "NUMERIC"},
+}
+
+// writeCSV writes the table to dir/<table>.csv with its columns as the header. NULL values
+// are written as empty fields.
+func writeCSV(dir string, table sqli.TableDump) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	file, err := utility.CreatePrivateFile(filepath.Join(dir, fileName(table.Name)+".csv"))
+	if err != nil {
+		return err
+	}
+	defer utility.SafeClose(file)
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(table.Columns); err != nil {
+		return err
+	}
+	for _, row := range table.Rows {
+		record := slices.Clone(row)
+		for i, value := range record {
+			if value == constant.NULL_VALUE {
+				record[i] = ""
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeSQLScript writes a SQL script that recreates every mirrored table, named schema.table,
+// in a SQLite database, and the loot_columns table with the column types reported by the
+// target. Loading it into a database file is left to the sqlite3 shell.
+func writeSQLScript(path string, schemas []sqli.SchemaDump) error {
+	file, err := utility.CreatePrivateFile(path)
+	if err != nil {
+		return err
+	}
+	defer utility.SafeClose(file)
+	writer := bufio.NewWriter(file)
+
+	fmt.Fprintf(writer, "-- Load with: sqlite3 loot.db < %s\n", filepath.Base(path))
+	fmt.Fprintln(writer, "BEGIN;")
+	fmt.Fprintf(writer, "DROP TABLE IF EXISTS %s;\n", columnsTable)
+	fmt.Fprintf(writer, "CREATE TABLE %s (schema_name TEXT, table_name TEXT, column_name TEXT, data_type TEXT, position INTEGER);\n", columnsTable)
+	for _, schema := range schemas {
+		for _, table := range schema.Tables {
+			for i, column := range table.Columns {
+				fmt.Fprintf(writer, "INSERT INTO %s VALUES (%s,%s,%s,%s,%d);\n", columnsTable,
+					constant.Quote(schema.Name), constant.Quote(table.Name), constant.Quote(column), constant.Quote(columnType(table, i)), i+1)
+			}
+		}
+	}
+
+	for _, schema := range schemas {
+		for _, table := range schema.Tables {
+			name := quoteIdentifier(schema.Name + "." + table.Name)
+			definitions := make([]string, len(table.Columns))
+			for i, column := range table.Columns {
+				definitions[i] = quoteIdentifier(column) + " " + affinity(columnType(table, i))
+			}
+			fmt.Fprintf(writer, "DROP TABLE IF EXISTS %s;\n", name)
+			fmt.Fprintf(writer, "CREATE TABLE %s (%s);\n", name, strings.Join(definitions, ", "))
+
+			for _, row := range table.Rows {
+				values := make([]string, len(table.Columns))
+				for i := range values {
+					values[i] = "NULL"
+					if i < len(row) {
+						values[i] = sqlValue(row[i])
+					}
+				}
+				fmt.Fprintf(writer, "INSERT INTO %s VALUES (%s);\n", name, strings.Join(values, ","))
+			}
+		}
+	}
+	fmt.Fprintln(writer, "COMMIT;")
+	return writer.Flush()
+}
+
+// columnType returns the data type of the ith column, empty when it is not known.
+func columnType(table sqli.TableDump, i int) string {
+	if i < len(table.ColumnTypes) {
+		return table.ColumnTypes[i]
+	}
+	return ""
+}
+
+// affinity returns the SQLite type a column of the target's data type is created with. The
+// target's name for it is never copied, as it is not to be trusted in a statement.
+func affinity(dataType string) string {
+	for _, candidate := range affinities {
+		if candidate.pattern.MatchString(dataType) {
+			return candidate.sqlite
+		}
+	}
+	return "TEXT"
+}
+
+// sqlValue returns a dumped value as a SQLite literal, turning the NULL placeholder back into NULL.
+func sqlValue(value string) string {
+	if value == constant.NULL_VALUE {
+		return "NULL"
+	}
+	return constant.Quote(value)
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// fileName makes a schema or table name safe to use as a file name.
+func fileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,143 @@
+package loot
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/sqli"
+)
+
+func TestAffinity(t *testing.T) {
+	tests := []struct {
+		dataType string
+		want     string
+	}{
+		{"integer", "INTEGER"},
+		{"bigint", "INTEGER"},
+		{"NUMBER(10)", "NUMERIC"},
+		{"character varying", "TEXT"},
+		{"VARCHAR2", "TEXT"},
+		{"nvarchar(max)", "TEXT"},
+		{"bytea", "BLOB"},
+		{"varbinary", "BLOB"},
+		{"double precision", "REAL"},
+		{"float", "REAL"},
+		{"decimal(10,2)", "NUMERIC"},
+		{"boolean", "NUMERIC"},
+		{"timestamp with time zone", "NUMERIC"},
+		{"", "TEXT"},
+		{"geometry", "TEXT"},
+		{"text); DROP TABLE x; --", "TEXT"},
+		{"int) ; ATTACH 'x' AS y; --", "INTEGER"},
+	}
+	for _, test := range tests {
+		if got := affinity(test.dataType); got != test.want {
+			t.Errorf("affinity(%q) = %q, want %q", test.dataType, got, test.want)
+		}
+	}
+}
+
+func TestSQLValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{constant.NULL_VALUE, "NULL"},
+		{"NULL", "'NULL'"},
+		{"", "''"},
+		{"O'Neil", "'O''Neil'"},
+	}
+	for _, test := range tests {
+		if got := sqlValue(test.value); got != test.want {
+			t.Errorf("sqlValue(%q) = %s, want %s", test.value, got, test.want)
+		}
+	}
+}
+
+func TestWriteSQLScript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), SQL_SCRIPT_FILE)
+	schemas := []sqli.SchemaDump{{Name: "public", Tables: []sqli.TableDump{{
+		Name:        "users",
+		Columns:     []string{"id", `we"ird`, "note"},
+		ColumnTypes: []string{"integer", "text); DROP TABLE x; --"},
+		Rows:        [][]string{{"1", "O'Neil", constant.NULL_VALUE}, {"2", "NULL"}},
+	}}}}
+	if err := writeSQLScript(path, schemas); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	script := string(content)
+	for _, want := range []string{
+		`CREATE TABLE "public.users" ("id" INTEGER, "we""ird" TEXT, "note" TEXT);`,
+		`INSERT INTO "public.users" VALUES ('1','O''Neil',NULL);`,
+		`INSERT INTO "public.users" VALUES ('2','NULL',NULL);`,
+		`INSERT INTO loot_columns VALUES ('public','users','we"ird','text); DROP TABLE x; --',2);`,
+		"COMMIT;",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script is missing %s\n%s", want, script)
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	dir := t.TempDir()
+	table := sqli.TableDump{
+		Name:    "a/b",
+		Columns: []string{"name", "note"},
+		Rows:    [][]string{{"carlos", constant.NULL_VALUE}, {"wiener", "NULL"}},
+	}
+	if err := writeCSV(dir, table); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(filepath.Join(dir, "a_b.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{{"name", "note"}, {"carlos", ""}, {"wiener", "NULL"}}
+	if !slices.EqualFunc(records, want, slices.Equal) {
+		t.Errorf("got %q, want %q", records, want)
+	}
+	if table.Rows[0][1] != constant.NULL_VALUE {
+		t.Error("writing the CSV changed the table's rows")
+	}
+	info, err := file.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("got mode %s, want the file readable by the owner only", info.Mode().Perm())
+	}
+}
+
+func TestFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"users", "users"},
+		{"dbo.users", "dbo.users"},
+		{`a/b\c:d*e?f"g<h>i|j`, "a_b_c_d_e_f_g_h_i_j"},
+		{"tab\there", "tab_here"},
+	}
+	for _, test := range tests {
+		if got := fileName(test.name); got != test.want {
+			t.Errorf("fileName(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package loot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/sqli"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/utility"
+)
+
+const (
+	FORMAT_CSV = "csv" // One CSV file per table
+	FORMAT_SQL = "sql" // A SQL script that recreates every table in a SQLite database
+
+	INDEX_FILE      = "loot.json" // Everything mirrored so far, read back on the next run
+	SQL_SCRIPT_FILE = "loot.sql"
+	CSV_DIR         = "csv"
+)
+
+var Formats = []string{FORMAT_CSV, FORMAT_SQL}
+
+// Mirror is a local copy of every dumped table, kept in a directory across runs.
+// The index holds the rows and column types, the CSV files and SQL script are
+// rewritten from it whenever a table is saved.
+type Mirror struct {
+	dir     string
+	formats []string
+	schemas []sqli.SchemaDump
+}
+
+// NewMirror opens the mirror in dir, creating the directory if needed and loading
+// what previous runs stored in it.
+func NewMirror(dir string, formats []string) (*Mirror, error) {
+	for _, format := range formats {
+		if !slices.Contains(Formats, format) {
+			return nil, fmt.Errorf("unknown loot format %q, expected one of %v", format, Formats)
+		}
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	m := &Mirror{dir: dir, formats: formats}
+	content, err := os.ReadFile(filepath.Join(dir, INDEX_FILE))
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &m.schemas); err != nil {
+		return nil, fmt.Errorf("corrupt %s: %w", INDEX_FILE, err)
+	}
+	return m, nil
+}
+
+// Dir returns the directory the mirror is kept in.
+func (m *Mirror) Dir() string {
+	return m.dir
+}
+
+// Rows returns the rows stored for the table. Rows stored with other columns are
+// not returned, as they cannot be resumed with the current ones.
+func (m *Mirror) Rows(schema string, table string, columns []string) ([][]string, error) {
+	stored := m.find(schema, table)
+	if stored == nil || !slices.Equal(stored.Columns, columns) {
+		return nil, nil
+	}
+	return slices.Clone(stored.Rows), nil
+}
+
+// Save stores the table in the index and rewrites the exports.
+func (m *Mirror) Save(schema string, table sqli.TableDump) error {
+	if stored := m.find(schema, table.Name); stored != nil {
+		*stored = table
+	} else {
+		index := slices.IndexFunc(m.schemas, func(s sqli.SchemaDump) bool { return s.Name == schema })
+		if index < 0 {
+			m.schemas = append(m.schemas, sqli.SchemaDump{Name: schema})
+			index = len(m.schemas) - 1
+		}
+		m.schemas[index].Tables = append(m.schemas[index].Tables, table)
+	}
+
+	if err := utility.WriteJSONFile(filepath.Join(m.dir, INDEX_FILE), m.schemas); err != nil {
+		return err
+	}
+	for _, format := range m.formats {
+		var err error
+		switch format {
+		case FORMAT_CSV:
+			err = writeCSV(filepath.Join(m.dir, CSV_DIR, fileName(schema)), table)
+		case FORMAT_SQL:
+			err = writeSQLScript(filepath.Join(m.dir, SQL_SCRIPT_FILE), m.schemas)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write %s export: %w", format, err)
+		}
+	}
+	return nil
+}
+
+func (m *Mirror) find(schema string, table string) *sqli.TableDump {
+	for i := range m.schemas {
+		if m.schemas[i].Name != schema {
+			continue
+		}
+		for j := range m.schemas[i].Tables {
+			if m.schemas[i].Tables[j].Name == table {
+				return &m.schemas[i].Tables[j]
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package loot
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/sqli"
+)
+
+func TestMirrorResumesAcrossRuns(t *testing.T) {
+	dir := t.TempDir()
+	columns := []string{"username", "password"}
+	rows := [][]string{{"carlos", constant.NULL_VALUE}, {"wiener", "NULL"}}
+
+	mirror, err := NewMirror(dir, Formats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mirror.Save("public", sqli.TableDump{Name: "users", Columns: columns, Rows: rows}); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{INDEX_FILE, SQL_SCRIPT_FILE, filepath.Join(CSV_DIR, "public", "users.csv")} {
+		if _, err := os.Stat(filepath.Join(dir, path)); err != nil {
+			t.Errorf("export missing: %s", err)
+		}
+	}
+
+	reopened, err := NewMirror(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		schema  string
+		table   string
+		columns []string
+		want    [][]string
+	}{
+		{"same columns", "public", "users", columns, rows},
+		{"changed columns", "public", "users", []string{"username"}, nil},
+		{"other schema", "main", "users", columns, nil},
+		{"unknown table", "public", "orders", columns, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := reopened.Rows(test.schema, test.table, test.columns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.EqualFunc(got, test.want, slices.Equal) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMirrorSaveReplacesTable(t *testing.T) {
+	mirror, err := NewMirror(t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns := []string{"name"}
+	for _, rows := range [][][]string{{{"a"}}, {{"a"}, {"b"}}} {
+		if err := mirror.Save("public", sqli.TableDump{Name: "t", Columns: columns, Rows: rows}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(mirror.schemas) != 1 || len(mirror.schemas[0].Tables) != 1 {
+		t.Fatalf("got %+v, want a single table", mirror.schemas)
+	}
+	if got, _ := mirror.Rows("public", "t", columns); len(got) != 2 {
+		t.Errorf("got %q, want the rows of the last save", got)
+	}
+}
+
+func TestNewMirrorErrors(t *testing.T) {
+	corrupt := t.TempDir()
+	if err := os.WriteFile(filepath.Join(corrupt, INDEX_FILE), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		dir     string
+		formats []string
+	}{
+		{"unknown format", t.TempDir(), []string{"xlsx"}},
+		{"corrupt index", corrupt, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := NewMirror(test.dir, test.formats); err == nil {
+				t.Error("want an error")
+			}
+		})
+	}
+}
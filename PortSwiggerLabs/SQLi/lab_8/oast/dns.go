@@ -0,0 +1,115 @@
+package oast
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
+)
+
+const (
+	dnsHeaderLength = 12
+	dnsTypeA        = 1
+	dnsClassIN      = 1
+)
+
+// serveDNS answers every query and records the ones that carry a token.
+func (c *Collector) serveDNS() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := c.dnsConn.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logger.Warningf("OAST DNS listener stopped: %s", err.Error())
+			}
+			return
+		}
+
+		query := append([]byte(nil), buf[:n]...)
+		name, questionEnd, qtype, err := parseQuestion(query)
+		if err != nil {
+			logger.Debugf("Ignoring malformed DNS query from %s: %s", addr, err.Error())
+			continue
+		}
+
+		// Data labels are everything in front of the token, e.g. <data>.<token>.<domain>
+		labels := strings.Split(strings.TrimSuffix(name, "."+c.Domain), ".")
+		if index := c.findToken(labels); index >= 0 {
+			c.record(Interaction{
+				Token:      strings.ToLower(labels[index]),
+				Protocol:   ProtocolDNS,
+				RemoteAddr: addr.String(),
+				Data:       strings.Join(labels[:index], "."),
+				Request:    name,
+			})
+		}
+
+		if _, err := c.dnsConn.WriteTo(c.buildAnswer(query[:questionEnd], qtype), addr); err != nil {
+			logger.Debugf("Failed to answer DNS query from %s: %s", addr, err.Error())
+		}
+	}
+}
+
+// parseQuestion extracts the queried name and type from the first question of a DNS message.
+func parseQuestion(message []byte) (string, int, uint16, error) {
+	if len(message) < dnsHeaderLength {
+		return "", 0, 0, errors.New("message shorter than header")
+	}
+	if binary.BigEndian.Uint16(message[4:6]) == 0 {
+		return "", 0, 0, errors.New("no question")
+	}
+
+	var labels []string
+	offset := dnsHeaderLength
+	for {
+		if offset >= len(message) {
+			return "", 0, 0, errors.New("truncated name")
+		}
+		length := int(message[offset])
+		offset++
+		if length == 0 {
+			break
+		}
+		if length > 63 || offset+length > len(message) {
+			return "", 0, 0, errors.New("invalid label")
+		}
+		labels = append(labels, string(message[offset:offset+length]))
+		offset += length
+	}
+
+	if offset+4 > len(message) {
+		return "", 0, 0, errors.New("truncated question")
+	}
+	qtype := binary.BigEndian.Uint16(message[offset : offset+2])
+	return strings.ToLower(strings.Join(labels, ".")), offset + 4, qtype, nil
+}
+
+// buildAnswer creates an authoritative response that echoes the question and,
+// for A queries, points the name at the collector.
+func (c *Collector) buildAnswer(question []byte, qtype uint16) []byte {
+	response := append([]byte(nil), question...)
+
+	// QR and AA set, keep the recursion desired bit of the query
+	flags := uint16(0x8400) | binary.BigEndian.Uint16(question[2:4])&0x0100
+	binary.BigEndian.PutUint16(response[2:4], flags)
+	binary.BigEndian.PutUint16(response[4:6], 1)  // QDCOUNT
+	binary.BigEndian.PutUint16(response[8:10], 0) // NSCOUNT
+	binary.BigEndian.PutUint16(response[10:12], 0)
+
+	ip := c.ResponseIP.To4()
+	if qtype != dnsTypeA || ip == nil {
+		binary.BigEndian.PutUint16(response[6:8], 0)
+		return response
+	}
+
+	binary.BigEndian.PutUint16(response[6:8], 1) // ANCOUNT
+	answer := []byte{0xc0, dnsHeaderLength}      // Pointer to the name in the question
+	answer = binary.BigEndian.AppendUint16(answer, dnsTypeA)
+	answer = binary.BigEndian.AppendUint16(answer, dnsClassIN)
+	answer = binary.BigEndian.AppendUint32(answer, 0) // TTL, never cache probes
+	answer = binary.BigEndian.AppendUint16(answer, uint16(len(ip)))
+	answer = append(answer, ip...)
+	return append(response, answer...)
+}
@@ -0,0 +1,138 @@
+package oast
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dnsQuery builds a query message with a single question for the name.
+func dnsQuery(name string, qtype uint16) []byte {
+	message := []byte{0x12, 0x34, 0x01, 0x00, 0, 1, 0, 0, 0, 0, 0, 0} // ID, RD set, one question
+	for _, label := range strings.Split(name, ".") {
+		message = append(message, byte(len(label)))
+		message = append(message, label...)
+	}
+	message = append(message, 0)
+	message = binary.BigEndian.AppendUint16(message, qtype)
+	return binary.BigEndian.AppendUint16(message, dnsClassIN)
+}
+
+func TestParseQuestion(t *testing.T) {
+	query := dnsQuery("X414243.ABC123.oast.local", dnsTypeA)
+	noQuestion := bytes.Clone(query)
+	noQuestion[5] = 0
+	longLabel := dnsQuery(strings.Repeat("a", 64)+".oast.local", dnsTypeA)
+
+	tests := []struct {
+		name        string
+		message     []byte
+		want        string
+		questionEnd int
+		qtype       uint16
+		wantErr     bool
+	}{
+		{"a query", query, "x414243.abc123.oast.local", len(query), dnsTypeA, false},
+		{"txt query", dnsQuery("abc123.oast.local", 16), "abc123.oast.local", len("abc123.oast.local") + 2 + dnsHeaderLength + 4, 16, false},
+		{"additional records ignored", append(bytes.Clone(query), 0, 0, 41), "x414243.abc123.oast.local", len(query), dnsTypeA, false},
+		{"shorter than the header", query[:10], "", 0, 0, true},
+		{"no question", noQuestion, "", 0, 0, true},
+		{"truncated name", query[:dnsHeaderLength+5], "", 0, 0, true},
+		{"label longer than 63 bytes", longLabel, "", 0, 0, true},
+		{"truncated type", query[:len(query)-3], "", 0, 0, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name, questionEnd, qtype, err := parseQuestion(test.message)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %q, want an error", name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name != test.want || questionEnd != test.questionEnd || qtype != test.qtype {
+				t.Errorf("got %q, %d, %d, want %q, %d, %d", name, questionEnd, qtype, test.want, test.questionEnd, test.qtype)
+			}
+		})
+	}
+}
+
+func TestBuildAnswer(t *testing.T) {
+	collector := NewCollector("oast.local", "", "")
+	collector.ResponseIP = net.IPv4(10, 1, 2, 3)
+
+	tests := []struct {
+		name    string
+		qtype   uint16
+		answers uint16
+	}{
+		{"a query gets the collector address", dnsTypeA, 1},
+		{"other queries get no answer", 28, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query := dnsQuery("abc123.oast.local", test.qtype)
+			response := collector.buildAnswer(query, test.qtype)
+
+			if !bytes.Equal(response[:2], query[:2]) {
+				t.Errorf("response ID %x, want the query's %x", response[:2], query[:2])
+			}
+			if flags := binary.BigEndian.Uint16(response[2:4]); flags != 0x8500 {
+				t.Errorf("flags %#04x, want a recursion desired authoritative response", flags)
+			}
+			if count := binary.BigEndian.Uint16(response[6:8]); count != test.answers {
+				t.Fatalf("%d answers, want %d", count, test.answers)
+			}
+			if test.answers == 0 {
+				if len(response) != len(query) {
+					t.Errorf("response has %d bytes after the question", len(response)-len(query))
+				}
+				return
+			}
+			if ip := response[len(response)-4:]; !net.IP(ip).Equal(collector.ResponseIP) {
+				t.Errorf("answer points at %s, want %s", net.IP(ip), collector.ResponseIP)
+			}
+		})
+	}
+}
+
+func TestServeDNSRecordsData(t *testing.T) {
+	collector := NewCollector("OAST.local.", "127.0.0.1:0", "")
+	if err := collector.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer collector.Close()
+	probe, err := collector.NewProbe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := net.Dial("udp", collector.dnsConn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for _, name := range []string{"x61.x62." + strings.ToUpper(probe.Host), "unknown.oast.local"} {
+		if _, err := conn.Write(dnsQuery(name, dnsTypeA)); err != nil {
+			t.Fatal(err)
+		}
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		if _, err := conn.Read(make([]byte, 512)); err != nil {
+			t.Fatalf("no answer for %s: %s", name, err)
+		}
+	}
+
+	interactions := collector.Interactions(probe.Token)
+	if len(interactions) != 1 {
+		t.Fatalf("got %d interactions, want 1", len(interactions))
+	}
+	if got := interactions[0]; got.Data != "x61.x62" || got.Protocol != ProtocolDNS || got.Token != probe.Token {
+		t.Errorf("got %+v, want the data labels in front of the token", got)
+	}
+}
@@ -0,0 +1,65 @@
+package oast
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
+)
+
+// handleHTTP records requests that carry a token either in the Host header
+// (<data>.<token>.<domain>) or as a path segment (/<token>/<data>).
+func (c *Collector) handleHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case PollPath:
+		c.handlePoll(w, r)
+		return
+	case ProbePath:
+		c.handleProbe(w)
+		return
+	}
+
+	requestLine := r.Method + " " + r.Host + r.URL.RequestURI()
+	interaction := Interaction{Protocol: ProtocolHTTP, RemoteAddr: r.RemoteAddr, Request: requestLine}
+
+	hostLabels := strings.Split(strings.Split(r.Host, ":")[0], ".")
+	pathSegments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if index := c.findToken(hostLabels); index >= 0 {
+		interaction.Token = strings.ToLower(hostLabels[index])
+		interaction.Data = strings.Join(hostLabels[:index], ".")
+	} else if index := c.findToken(pathSegments); index >= 0 {
+		interaction.Token = strings.ToLower(pathSegments[index])
+		interaction.Data = strings.Join(pathSegments[index+1:], "/")
+	}
+
+	if !c.record(interaction) {
+		logger.Debugf("Ignoring OAST HTTP request without a known token: %s", requestLine)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handlePoll returns the interactions of the token given in the query string as JSON.
+func (c *Collector) handlePoll(w http.ResponseWriter, r *http.Request) {
+	interactions := c.Interactions(strings.ToLower(r.URL.Query().Get("token")))
+	if interactions == nil {
+		interactions = []Interaction{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(interactions); err != nil {
+		logger.Debugf("Failed to write OAST poll response: %s", err.Error())
+	}
+}
+
+// handleProbe registers a new probe and returns it as JSON.
+func (c *Collector) handleProbe(w http.ResponseWriter) {
+	probe, err := c.NewProbe()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(probe); err != nil {
+		logger.Debugf("Failed to write OAST probe response: %s", err.Error())
+	}
+}
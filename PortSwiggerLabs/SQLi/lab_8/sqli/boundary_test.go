@@ -0,0 +1,145 @@
+package sqli
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/utility"
+)
+
+// contextLab serves a page whose query only parses when the category matches the
+// pattern. The page lists the product when the two numbers in the injected condition are
+// equal, and an error page when the query does not parse.
+func contextLab(pattern *regexp.Regexp) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		match := pattern.FindStringSubmatch(r.URL.Query().Get("category"))
+		if match == nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, "<html><h1>Internal Server Error</h1></html>")
+			return
+		}
+		products := ""
+		if match[1] == match[2] {
+			products = "<tr><td>Eco Boat</td><td>$42.00</td></tr>"
+		}
+		fmt.Fprintf(w, "<html><h1>Gifts</h1><table>%s</table><p>Footer with some text</p></html>", products)
+	}))
+}
+
+func TestFindBoundary(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		pattern  string
+		want     Boundary
+		wantErr  bool
+	}{
+		{
+			"numeric", "1", `^1 AND (\d)=(\d)$`,
+			Boundary{Context: "numeric", Operator: "AND"}, false,
+		},
+		{
+			"single quote and double dash", "Gifts", `^Gifts' AND (\d)=(\d)--$`,
+			Boundary{Context: "single quote", Prefix: "'", Suffix: "--", Comment: "--", Operator: "AND"}, false,
+		},
+		{
+			"parenthesized with hash", "Gifts", `^Gifts'\) AND (\d)=(\d)#$`,
+			Boundary{Context: "parenthesized single quote", Prefix: "')", Suffix: "#", Comment: "#", Operator: "AND"}, false,
+		},
+		{
+			"balanced when comments are stripped", "Gifts", `^Gifts' AND (\d)=(\d) AND 'qzx'='qzx$`,
+			Boundary{Context: "single quote", Prefix: "'", Suffix: " AND 'qzx'='qzx", Operator: "AND"}, false,
+		},
+		{
+			"only OR", "Gifts", `^Gifts' OR (\d)=(\d)-- $`,
+			Boundary{Context: "single quote", Prefix: "'", Suffix: "-- ", Comment: "-- ", Operator: "OR"}, false,
+		},
+		{"not injectable", "Gifts", `^Gifts$`, Boundary{}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := contextLab(regexp.MustCompile(test.pattern))
+			defer server.Close()
+			client, err := utility.NewClient("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			point := NewInjectionPoint("GET", server.URL+"/filter?category="+test.original, LocationQuery, "category")
+
+			boundary, err := FindBoundary(client, point, &AutoOracle{})
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %s, want an error", boundary)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if boundary != test.want {
+				t.Errorf("got %s, want %s", boundary, test.want)
+			}
+		})
+	}
+}
+
+func TestBoundaryCandidates(t *testing.T) {
+	operators, styles := len(constant.LogicalOperators), len(constant.CommentStyles)
+	for _, context := range constant.InjectionContexts {
+		t.Run(context.Name, func(t *testing.T) {
+			candidates := boundaryCandidates(context)
+			// Every comment style plus the balancing clause, or no suffix for a bare numeric context
+			if want := operators * (styles + 1); len(candidates) != want {
+				t.Errorf("got %d candidates, want %d", len(candidates), want)
+			}
+			seen := map[Boundary]bool{}
+			for _, candidate := range candidates {
+				if seen[candidate] {
+					t.Errorf("%s is listed twice", candidate)
+				}
+				seen[candidate] = true
+				if candidate.Prefix != context.Prefix || candidate.Context != context.Name {
+					t.Errorf("%s does not belong to the context", candidate)
+				}
+				if candidate.Comment != "" && candidate.Suffix != candidate.Comment {
+					t.Errorf("%s has a comment that is not its suffix", candidate)
+				}
+			}
+		})
+	}
+}
+
+func TestBoundaryCondition(t *testing.T) {
+	boundary := Boundary{Prefix: "')", Suffix: "-- ", Operator: "OR"}
+	if got, want := boundary.Condition("1=1"), "') OR 1=1-- "; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := boundary.Wrap(" UNION SELECT NULL"), "') UNION SELECT NULL-- "; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBoundaryTakesClauses(t *testing.T) {
+	tests := []struct {
+		name     string
+		boundary Boundary
+		want     bool
+	}{
+		{"numeric without suffix", Boundary{Context: "numeric"}, true},
+		{"comment", Boundary{Context: "single quote", Prefix: "'", Suffix: "-- ", Comment: "-- "}, true},
+		{"hash comment", Boundary{Context: "single quote", Prefix: "'", Suffix: "#", Comment: "#"}, true},
+		{"balanced quote", Boundary{Context: "single quote", Prefix: "'", Suffix: " AND 'qzx'='qzx"}, false},
+		{"balanced parenthesis", Boundary{Context: "parenthesized numeric", Prefix: ")", Suffix: " AND (1=1"}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.boundary.TakesClauses(); got != test.want {
+				t.Errorf("got %t, want %t", got, test.want)
+			}
+		})
+	}
+}
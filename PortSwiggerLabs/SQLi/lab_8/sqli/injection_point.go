@@ -0,0 +1,339 @@
+package sqli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/tamper"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/utility"
+)
+
+// Location describes where in the request the payload is injected.
+type Location string
+
+const (
+	LocationQuery  Location = "query"  // URL query parameter
+	LocationForm   Location = "form"   // application/x-www-form-urlencoded body field
+	LocationCookie Location = "cookie" // Cookie value, e.g. PortSwigger's TrackingId
+	LocationHeader Location = "header" // Request header value
+	LocationJSON   Location = "json"   // JSON body value addressed by a dotted path, e.g. user.id or items.0.name
+	LocationXML    Location = "xml"    // Text of the first XML body element with the given name
+)
+
+var Locations = []Location{
+	LocationQuery,
+	LocationForm,
+	LocationCookie,
+	LocationHeader,
+	LocationJSON,
+	LocationXML,
+}
+
+// InjectionPoint describes a request and the single value in it that carries the payload.
+// Payloads are appended to the original value, so the rest of the request stays intact.
+type InjectionPoint struct {
+	Method   string
+	URL      string
+	Location Location
+	Name     string       // Parameter, cookie or header name, JSON path or XML element name
+	Body     string       // Form, JSON or XML body
+	Headers  http.Header  // Extra headers sent with every request
+	Cookie   string       // Raw Cookie header, e.g. "TrackingId=abc; session=xyz"
+	Tampers  tamper.Chain // Applied to every payload before it is encoded into the request
+}
+
+// NewInjectionPoint returns an injection point without body, headers or cookies.
+func NewInjectionPoint(method string, rawURL string, location Location, name string) InjectionPoint {
+	return InjectionPoint{
+		Method:   strings.ToUpper(method),
+		URL:      rawURL,
+		Location: location,
+		Name:     name,
+		Headers:  http.Header{},
+	}
+}
+
+// String describes the injection point for log messages.
+func (p InjectionPoint) String() string {
+	return fmt.Sprintf("%s %s (%s %s)", p.Method, p.URL, p.Location, p.Name)
+}
+
+// Original returns the value found at the injection point before any payload is added.
+func (p InjectionPoint) Original() (string, error) {
+	switch p.Location {
+	case LocationQuery:
+		parsedURL, err := url.Parse(p.URL)
+		if err != nil {
+			return "", fmt.Errorf("invalid URL %s: %w", p.URL, err)
+		}
+		return findPair(parsedURL.RawQuery, "&", p.Name, url.QueryUnescape)
+	case LocationForm:
+		return findPair(p.Body, "&", p.Name, url.QueryUnescape)
+	case LocationCookie:
+		return findPair(p.Cookie, ";", p.Name, url.QueryUnescape)
+	case LocationHeader:
+		if values := p.Headers.Values(p.Name); len(values) > 0 {
+			return values[0], nil
+		}
+		return "", fmt.Errorf("header %s not found", p.Name)
+	case LocationJSON:
+		var document any
+		if err := json.Unmarshal([]byte(p.Body), &document); err != nil {
+			return "", fmt.Errorf("invalid JSON body: %w", err)
+		}
+		value, err := jsonGet(document, strings.Split(p.Name, "."))
+		if err != nil {
+			return "", err
+		}
+		return jsonScalarString(value), nil
+	case LocationXML:
+		match := xmlElementPattern(p.Name).FindStringSubmatch(p.Body)
+		if match == nil {
+			return "", fmt.Errorf("XML element %s not found", p.Name)
+		}
+		return xmlUnescape(match[2]), nil
+	}
+	return "", fmt.Errorf("unknown injection location %q", p.Location)
+}
+
+// Value returns the original value with the tampered payload appended, as it is sent.
+func (p InjectionPoint) Value(payload string) (string, error) {
+	original, err := p.Original()
+	if err != nil {
+		return "", fmt.Errorf("invalid injection point %s: %w", p, err)
+	}
+	return original + p.Tampers.Apply(payload), nil
+}
+
+// Request renders the full HTTP request with the payload appended to the original value.
+func (p InjectionPoint) Request(payload string) (*http.Request, error) {
+	value, err := p.Value(payload)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debugf("Injecting into %s %s: %s", p.Location, p.Name, value)
+
+	requestURL, body, contentType := p.URL, p.Body, ""
+	headers := p.Headers.Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
+	cookie := p.Cookie
+
+	switch p.Location {
+	case LocationQuery:
+		parsedURL, err := url.Parse(p.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL %s: %w", p.URL, err)
+		}
+		parsedURL.RawQuery = replacePair(parsedURL.RawQuery, "&", p.Name, url.QueryEscape(value))
+		requestURL = parsedURL.String()
+	case LocationForm:
+		body = replacePair(p.Body, "&", p.Name, url.QueryEscape(value))
+		contentType = "application/x-www-form-urlencoded"
+	case LocationCookie:
+		cookie = replacePair(p.Cookie, ";", p.Name, escapeCookieValue(value))
+	case LocationHeader:
+		headers.Set(p.Name, value)
+	case LocationJSON:
+		var document any
+		if err := json.Unmarshal([]byte(p.Body), &document); err != nil {
+			return nil, fmt.Errorf("invalid JSON body: %w", err)
+		}
+		document, err = jsonSet(document, strings.Split(p.Name, "."), value)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := json.Marshal(document)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode JSON body: %w", err)
+		}
+		body = string(encoded)
+		contentType = "application/json"
+	case LocationXML:
+		match := xmlElementPattern(p.Name).FindStringSubmatchIndex(p.Body)
+		body = p.Body[:match[4]] + xmlEscape(value) + p.Body[match[5]:]
+		contentType = "application/xml"
+	}
+
+	var bodyReader io.Reader
+	if body != "" {
+		bodyReader = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(p.Method, requestURL, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for %s: %w", requestURL, err)
+	}
+
+	req.Header = headers
+	if contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if cookie != "" {
+		req.Header.Set("Cookie", cookie)
+	}
+	return req, nil
+}
+
+// sendPayload renders the payload at the injection point and sends it.
+func sendPayload(client *utility.HTTPClient, point InjectionPoint, payload string) (*http.Response, error) {
+	response, _, err := sendTimedPayload(client, point, payload)
+	return response, err
+}
+
+// sendTimedPayload renders the payload at the injection point, sends it and reports how long it took.
+func sendTimedPayload(client *utility.HTTPClient, point InjectionPoint, payload string) (*http.Response, time.Duration, error) {
+	req, err := point.Request(payload)
+	if err != nil {
+		return nil, 0, err
+	}
+	return client.SendRequest(req)
+}
+
+// findPair returns the decoded value of name in a list of name=value pairs.
+func findPair(pairs string, separator string, name string, unescape func(string) (string, error)) (string, error) {
+	for _, pair := range strings.Split(pairs, separator) {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		if key == name {
+			decoded, err := unescape(value)
+			if err != nil {
+				return value, nil
+			}
+			return decoded, nil
+		}
+	}
+	return "", fmt.Errorf("%s not found", name)
+}
+
+// replacePair sets the already encoded value of name, keeping the order of all other pairs.
+func replacePair(pairs string, separator string, name string, encodedValue string) string {
+	parts := strings.Split(pairs, separator)
+	for i, pair := range parts {
+		key, _, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		if key == name {
+			leading := pair[:len(pair)-len(strings.TrimLeft(pair, " "))]
+			parts[i] = leading + key + "=" + encodedValue
+			break
+		}
+	}
+	return strings.Join(parts, separator)
+}
+
+// escapeCookieValue percent-encodes the characters that cannot appear in a cookie value.
+func escapeCookieValue(value string) string {
+	var escaped strings.Builder
+	for _, b := range []byte(value) {
+		switch {
+		case b <= ' ' || b >= 0x7f || b == '"' || b == ',' || b == ';' || b == '\\' || b == '%' || b == '+':
+			fmt.Fprintf(&escaped, "%%%02X", b)
+		default:
+			escaped.WriteByte(b)
+		}
+	}
+	return escaped.String()
+}
+
+func jsonGet(document any, path []string) (any, error) {
+	current := document
+	for _, key := range path {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[key]
+			if !ok {
+				return nil, fmt.Errorf("JSON key %s not found", key)
+			}
+			current = value
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("JSON index %s out of range", key)
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("JSON path %s does not lead to a value", strings.Join(path, "."))
+		}
+	}
+	return current, nil
+}
+
+// jsonSet replaces the value at path with a string, turning numbers into strings if needed.
+func jsonSet(document any, path []string, value string) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	switch node := document.(type) {
+	case map[string]any:
+		child, ok := node[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("JSON key %s not found", path[0])
+		}
+		updated, err := jsonSet(child, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		node[path[0]] = updated
+		return node, nil
+	case []any:
+		index, err := strconv.Atoi(path[0])
+		if err != nil || index < 0 || index >= len(node) {
+			return nil, fmt.Errorf("JSON index %s out of range", path[0])
+		}
+		updated, err := jsonSet(node[index], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = updated
+		return node, nil
+	}
+	return nil, fmt.Errorf("JSON path element %s does not lead to a value", path[0])
+}
+
+func jsonScalarString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+func xmlElementPattern(name string) *regexp.Regexp {
+	quoted := regexp.QuoteMeta(name)
+	return regexp.MustCompile(`(<` + quoted + `(?:\s[^>]*)?>)([^<]*)(</` + quoted + `>)`)
+}
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+var xmlUnescaper = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'", "&amp;", "&")
+
+// xmlCharRefPattern matches numeric character references such as &#x55;.
+var xmlCharRefPattern = regexp.MustCompile(`&#(?:x[0-9A-Fa-f]+|[0-9]+);`)
+
+// xmlEscape escapes markup characters but keeps numeric character references,
+// so payloads encoded by the xmlentity tamper reach the server as entities.
+func xmlEscape(value string) string {
+	var escaped strings.Builder
+	last := 0
+	for _, ref := range xmlCharRefPattern.FindAllStringIndex(value, -1) {
+		escaped.WriteString(xmlEscaper.Replace(value[last:ref[0]]))
+		escaped.WriteString(value[ref[0]:ref[1]])
+		last = ref[1]
+	}
+	escaped.WriteString(xmlEscaper.Replace(value[last:]))
+	return escaped.String()
+}
+
+func xmlUnescape(value string) string {
+	return xmlUnescaper.Replace(value)
+}
@@ -0,0 +1,157 @@
+package sqli
+
+import (
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestInjectionPointRequest(t *testing.T) {
+	point := func(location Location, name string, body string) InjectionPoint {
+		p := NewInjectionPoint("post", "https://lab.net/filter?category=Gifts&sort=asc", location, name)
+		p.Body = body
+		p.Cookie = "TrackingId=abc; session=xyz"
+		p.Headers.Set("X-Forwarded-For", "10.0.0.1")
+		return p
+	}
+	const payload = `' AND "a"<'b'--`
+
+	tests := []struct {
+		name        string
+		point       InjectionPoint
+		url         string
+		body        string
+		cookie      string
+		header      string // Value of X-Forwarded-For
+		contentType string
+	}{
+		{
+			"query", point(LocationQuery, "category", ""),
+			"https://lab.net/filter?category=Gifts%27+AND+%22a%22%3C%27b%27--&sort=asc",
+			"", "TrackingId=abc; session=xyz", "10.0.0.1", "",
+		},
+		{
+			"form", point(LocationForm, "id", "id=1&csrf=t"),
+			"https://lab.net/filter?category=Gifts&sort=asc",
+			"id=1%27+AND+%22a%22%3C%27b%27--&csrf=t", "TrackingId=abc; session=xyz", "10.0.0.1", "application/x-www-form-urlencoded",
+		},
+		{
+			"cookie", point(LocationCookie, "TrackingId", ""),
+			"https://lab.net/filter?category=Gifts&sort=asc",
+			"", "TrackingId=abc'%20AND%20%22a%22<'b'--; session=xyz", "10.0.0.1", "",
+		},
+		{
+			"header", point(LocationHeader, "X-Forwarded-For", ""),
+			"https://lab.net/filter?category=Gifts&sort=asc",
+			"", "TrackingId=abc; session=xyz", `10.0.0.1' AND "a"<'b'--`, "",
+		},
+		{
+			"json path", point(LocationJSON, "items.1.id", `{"items":[{"id":1},{"id":2}]}`),
+			"https://lab.net/filter?category=Gifts&sort=asc",
+			`{"items":[{"id":1},{"id":"2' AND \"a\"\u003c'b'--"}]}`, "TrackingId=abc; session=xyz", "10.0.0.1", "application/json",
+		},
+		{
+			"xml element", point(LocationXML, "productId", `<stock><productId attr="x">1</productId><storeId>2</storeId></stock>`),
+			"https://lab.net/filter?category=Gifts&sort=asc",
+			`<stock><productId attr="x">1' AND "a"&lt;'b'--</productId><storeId>2</storeId></stock>`, "TrackingId=abc; session=xyz", "10.0.0.1", "application/xml",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := test.point.Request(payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body := ""
+			if req.Body != nil {
+				content, err := io.ReadAll(req.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = string(content)
+			}
+
+			if req.Method != http.MethodPost {
+				t.Errorf("method %s, want POST", req.Method)
+			}
+			if got := req.URL.String(); got != test.url {
+				t.Errorf("url %s, want %s", got, test.url)
+			}
+			if body != test.body {
+				t.Errorf("body %s, want %s", body, test.body)
+			}
+			if got := req.Header.Get("Cookie"); got != test.cookie {
+				t.Errorf("cookie %s, want %s", got, test.cookie)
+			}
+			if got := req.Header.Get("X-Forwarded-For"); got != test.header {
+				t.Errorf("header %s, want %s", got, test.header)
+			}
+			if got := req.Header.Get("Content-Type"); got != test.contentType {
+				t.Errorf("content type %s, want %s", got, test.contentType)
+			}
+			if test.point.Headers.Get("X-Forwarded-For") != "10.0.0.1" {
+				t.Error("the request changed the point's headers")
+			}
+		})
+	}
+}
+
+func TestInjectionPointOriginal(t *testing.T) {
+	tests := []struct {
+		name     string
+		location Location
+		param    string
+		body     string
+		want     string
+		wantErr  bool
+	}{
+		{"decoded query value", LocationQuery, "category", "", "Corporate gifts", false},
+		{"missing query parameter", LocationQuery, "id", "", "", true},
+		{"form", LocationForm, "csrf", "id=1&csrf=a%2Bb", "a+b", false},
+		{"cookie", LocationCookie, "session", "", "xyz", false},
+		{"missing header", LocationHeader, "X-Missing", "", "", true},
+		{"json number", LocationJSON, "user.id", `{"user":{"id":15}}`, "15", false},
+		{"json null", LocationJSON, "user.name", `{"user":{"name":null}}`, "", false},
+		{"json index out of range", LocationJSON, "items.3", `{"items":[1]}`, "", true},
+		{"invalid json", LocationJSON, "id", `{`, "", true},
+		{"xml entities", LocationXML, "name", "<a><name>O&apos;Neil &amp; co</name></a>", "O'Neil & co", false},
+		{"missing xml element", LocationXML, "id", "<a/>", "", true},
+		{"unknown location", Location("path"), "id", "", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewInjectionPoint("GET", "https://lab.net/filter?category=Corporate+gifts", test.location, test.param)
+			p.Body = test.body
+			p.Cookie = "TrackingId=abc; session=xyz"
+			got, err := p.Original()
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestXMLEscape(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"1 UNION SELECT 'a'<'b'", "1 UNION SELECT 'a'&lt;'b'"},
+		{"&#x55;&#78;ION & more", "&#x55;&#78;ION &amp; more"},
+		{"&#xZZ;", "&amp;#xZZ;"},
+	}
+	for _, test := range tests {
+		if got := xmlEscape(test.value); got != test.want {
+			t.Errorf("xmlEscape(%q) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
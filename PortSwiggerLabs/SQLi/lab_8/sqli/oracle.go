@@ -0,0 +1,322 @@
+package sqli
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
+)
+
+// Oracle decides whether a response looks like the "true" page of the target:
+// the original query ran, or the injected condition held.
+// Calibrate must be called with a known true and a known false response before Evaluate.
+// Calibrating again replaces what the oracle learned, so phases that need different
+// baselines use separate oracles.
+type Oracle interface {
+	Name() string
+	Calibrate(truthy *Response, falsy *Response) error
+	Evaluate(response *Response) bool
+}
+
+// Oracle names accepted on the command line, some take an argument after a colon
+// (e.g. status:200, regex:Welcome back, selector:table tr, time:2s).
+const (
+	ORACLE_AUTO       = "auto"
+	ORACLE_SIMILARITY = "similarity"
+	ORACLE_STATUS     = "status"
+	ORACLE_LENGTH     = "length"
+	ORACLE_HASH       = "hash"
+	ORACLE_REGEX      = "regex"
+	ORACLE_CONTAINS   = "contains"
+	ORACLE_SELECTOR   = "selector"
+	ORACLE_TIME       = "time"
+)
+
+var Oracles = []string{
+	ORACLE_AUTO,
+	ORACLE_SIMILARITY,
+	ORACLE_STATUS,
+	ORACLE_LENGTH,
+	ORACLE_HASH,
+	ORACLE_REGEX,
+	ORACLE_CONTAINS,
+	ORACLE_SELECTOR,
+	ORACLE_TIME,
+}
+
+// NewOracle builds an oracle from its command-line form, name[:argument].
+func NewOracle(spec string) (Oracle, error) {
+	name, argument, _ := strings.Cut(spec, ":")
+	switch name {
+	case ORACLE_AUTO, "":
+		return &AutoOracle{}, nil
+	case ORACLE_SIMILARITY:
+		return &SimilarityOracle{}, nil
+	case ORACLE_STATUS:
+		if argument == "" {
+			return &StatusOracle{}, nil
+		}
+		code, err := strconv.Atoi(argument)
+		if err != nil {
+			return nil, fmt.Errorf("invalid status code %q: %w", argument, err)
+		}
+		return &StatusOracle{Code: code}, nil
+	case ORACLE_LENGTH:
+		return &LengthOracle{}, nil
+	case ORACLE_HASH:
+		return &HashOracle{}, nil
+	case ORACLE_REGEX:
+		pattern, err := regexp.Compile(argument)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %w", argument, err)
+		}
+		return &MatchOracle{Pattern: pattern, Kind: ORACLE_REGEX}, nil
+	case ORACLE_CONTAINS:
+		if argument == "" {
+			return nil, fmt.Errorf("contains oracle needs a string, e.g. contains:Welcome back")
+		}
+		return &MatchOracle{Pattern: regexp.MustCompile(regexp.QuoteMeta(argument)), Kind: ORACLE_CONTAINS}, nil
+	case ORACLE_SELECTOR:
+		if argument == "" {
+			return nil, fmt.Errorf("selector oracle needs a CSS selector, e.g. selector:table tr")
+		}
+		return &SelectorOracle{Selector: argument}, nil
+	case ORACLE_TIME:
+		if argument == "" {
+			return &TimeOracle{}, nil
+		}
+		threshold, err := time.ParseDuration(argument)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time threshold %q: %w", argument, err)
+		}
+		return &TimeOracle{Threshold: threshold}, nil
+	default:
+		return nil, fmt.Errorf("unknown oracle %q, expected one of %v", name, Oracles)
+	}
+}
+
+// verifyCalibration fails when the calibrated oracle cannot tell the baselines apart.
+func verifyCalibration(oracle Oracle, truthy *Response, falsy *Response) error {
+	if !oracle.Evaluate(truthy) || oracle.Evaluate(falsy) {
+		return fmt.Errorf("%s oracle cannot distinguish the true and false responses", oracle.Name())
+	}
+	return nil
+}
+
+// StatusOracle treats one status code as true. Without a configured code it learns
+// the code of the true response.
+type StatusOracle struct {
+	Code int
+
+	learned int
+}
+
+func (o *StatusOracle) Name() string {
+	return ORACLE_STATUS
+}
+
+func (o *StatusOracle) Calibrate(truthy *Response, falsy *Response) error {
+	o.learned = o.Code
+	if o.learned == 0 {
+		o.learned = truthy.StatusCode
+	}
+	return verifyCalibration(o, truthy, falsy)
+}
+
+func (o *StatusOracle) Evaluate(response *Response) bool {
+	return response.StatusCode == o.learned
+}
+
+// LengthOracle treats a response as true when its body length is closer to the
+// true baseline than to the false one.
+type LengthOracle struct {
+	trueLength  int
+	falseLength int
+}
+
+func (o *LengthOracle) Name() string {
+	return ORACLE_LENGTH
+}
+
+func (o *LengthOracle) Calibrate(truthy *Response, falsy *Response) error {
+	o.trueLength = len(truthy.Body)
+	o.falseLength = len(falsy.Body)
+	return verifyCalibration(o, truthy, falsy)
+}
+
+func (o *LengthOracle) Evaluate(response *Response) bool {
+	return abs(len(response.Body)-o.trueLength) < abs(len(response.Body)-o.falseLength)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// HashOracle treats a response as true only when its body is identical to the true baseline.
+// It suits static pages, any dynamic fragment makes every response false.
+type HashOracle struct {
+	trueHash [sha256.Size]byte
+}
+
+func (o *HashOracle) Name() string {
+	return ORACLE_HASH
+}
+
+func (o *HashOracle) Calibrate(truthy *Response, falsy *Response) error {
+	o.trueHash = sha256.Sum256(truthy.Body)
+	return verifyCalibration(o, truthy, falsy)
+}
+
+func (o *HashOracle) Evaluate(response *Response) bool {
+	return sha256.Sum256(response.Body) == o.trueHash
+}
+
+// MatchOracle looks for a regex or substring in the body, e.g. the "Welcome back"
+// message of a blind lab. The pattern may mark either the true or the false page.
+type MatchOracle struct {
+	Pattern *regexp.Regexp
+	Kind    string // ORACLE_REGEX or ORACLE_CONTAINS, the form it was given in
+
+	negate bool
+}
+
+func (o *MatchOracle) Name() string {
+	if o.Kind == "" {
+		return ORACLE_REGEX
+	}
+	return o.Kind
+}
+
+func (o *MatchOracle) Calibrate(truthy *Response, falsy *Response) error {
+	o.negate = !o.Pattern.Match(truthy.Body) && o.Pattern.Match(falsy.Body)
+	return verifyCalibration(o, truthy, falsy)
+}
+
+func (o *MatchOracle) Evaluate(response *Response) bool {
+	return o.Pattern.Match(response.Body) != o.negate
+}
+
+// SelectorOracle compares the text of the elements matched by a CSS selector,
+// ignoring the rest of the page.
+type SelectorOracle struct {
+	Selector string
+
+	trueText string
+}
+
+func (o *SelectorOracle) Name() string {
+	return ORACLE_SELECTOR
+}
+
+func (o *SelectorOracle) Calibrate(truthy *Response, falsy *Response) error {
+	o.trueText = o.selectText(truthy)
+	return verifyCalibration(o, truthy, falsy)
+}
+
+func (o *SelectorOracle) Evaluate(response *Response) bool {
+	return o.selectText(response) == o.trueText
+}
+
+func (o *SelectorOracle) selectText(response *Response) string {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(response.Body))
+	if err != nil {
+		logger.Debugf("Failed to parse response HTML: %s", err.Error())
+		return ""
+	}
+	var texts []string
+	doc.Find(o.Selector).Each(func(_ int, selection *goquery.Selection) {
+		texts = append(texts, strings.TrimSpace(selection.Text()))
+	})
+	return strings.Join(texts, "\n")
+}
+
+// TimeOracle compares response times against a threshold. Without a configured
+// threshold it uses the midpoint between the two baselines; a configured threshold
+// always treats slow responses as true.
+type TimeOracle struct {
+	Threshold time.Duration
+
+	threshold time.Duration
+	slowTrue  bool
+}
+
+func (o *TimeOracle) Name() string {
+	return ORACLE_TIME
+}
+
+func (o *TimeOracle) Calibrate(truthy *Response, falsy *Response) error {
+	o.threshold = o.Threshold
+	o.slowTrue = true
+	if o.threshold == 0 {
+		o.threshold = (truthy.Duration + falsy.Duration) / 2
+		o.slowTrue = truthy.Duration > falsy.Duration
+	}
+	return verifyCalibration(o, truthy, falsy)
+}
+
+func (o *TimeOracle) Evaluate(response *Response) bool {
+	return (response.Duration > o.threshold) == o.slowTrue
+}
+
+// SimilarityOracle treats a response as true when its page is more similar to the
+// true baseline than to the false one, after stripping dynamic content.
+type SimilarityOracle struct {
+	truthy *Response
+	falsy  *Response
+}
+
+func (o *SimilarityOracle) Name() string {
+	return ORACLE_SIMILARITY
+}
+
+func (o *SimilarityOracle) Calibrate(truthy *Response, falsy *Response) error {
+	if pagesMatch(truthy, falsy) {
+		return fmt.Errorf("%s oracle cannot distinguish the true and false responses", o.Name())
+	}
+	o.truthy = truthy
+	o.falsy = falsy
+	return nil
+}
+
+func (o *SimilarityOracle) Evaluate(response *Response) bool {
+	trueRatio := Similarity(response, o.truthy)
+	falseRatio := Similarity(response, o.falsy)
+	logger.Debugf("Similarity to true baseline: %.3f, to false baseline: %.3f", trueRatio, falseRatio)
+	return trueRatio > falseRatio
+}
+
+// AutoOracle uses the status code when the baselines differ in status and
+// otherwise compares page similarity, which matches how the PortSwigger labs behave.
+type AutoOracle struct {
+	status     StatusOracle
+	similarity SimilarityOracle
+	useStatus  bool
+}
+
+func (o *AutoOracle) Name() string {
+	return ORACLE_AUTO
+}
+
+func (o *AutoOracle) Calibrate(truthy *Response, falsy *Response) error {
+	o.useStatus = truthy.StatusCode != falsy.StatusCode
+	if o.useStatus {
+		return o.status.Calibrate(truthy, falsy)
+	}
+	return o.similarity.Calibrate(truthy, falsy)
+}
+
+func (o *AutoOracle) Evaluate(response *Response) bool {
+	if o.useStatus {
+		return o.status.Evaluate(response)
+	}
+	return o.similarity.Evaluate(response)
+}
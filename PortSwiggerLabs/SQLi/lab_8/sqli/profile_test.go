@@ -0,0 +1,102 @@
+package sqli
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestProfileColumns(t *testing.T) {
+	tests := []struct {
+		name      string
+		lab       unionLab
+		want      []ColumnProfile // Offsets are only compared by their order
+		reflected []int
+		wantErr   bool
+	}{
+		{
+			"portswigger products",
+			unionLab{columns: []labColumn{{"string", "th"}, {"string", "td"}}},
+			[]ColumnProfile{
+				{Index: 0, String: true, Reflected: true, Element: "table > tbody > tr > th"},
+				{Index: 1, String: true, Reflected: true, Element: "table > tbody > tr > td"},
+			},
+			[]int{0, 1}, false,
+		},
+		{
+			"mixed types on oracle",
+			unionLab{columns: []labColumn{{"integer", ""}, {"string", "td"}, {"date", "td"}, {"string", ""}}, dualTable: "dual"},
+			[]ColumnProfile{
+				{Index: 0, Integer: true},
+				{Index: 1, String: true, Reflected: true, Element: "table > tbody > tr > td"},
+				{Index: 2, Date: true},
+				{Index: 3, String: true},
+			},
+			[]int{1}, false,
+		},
+		{
+			"rendered integer",
+			unionLab{columns: []labColumn{{"integer", "td"}}},
+			[]ColumnProfile{{Index: 0, Integer: true, Reflected: true, Element: "table > tbody > tr > td"}},
+			nil, false,
+		},
+		{"wrong column count", unionLab{columns: []labColumn{{"string", "td"}}}, nil, nil, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := test.lab.start()
+			defer server.Close()
+			client, point := newLabPoint(t, server)
+			count := len(test.want)
+			if test.wantErr {
+				count = len(test.lab.columns) + 1
+			}
+
+			columns, err := ProfileColumns(client, point, Boundary{Prefix: "'", Suffix: "--"}, newLabOracle(t), count)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %s, want an error", columns)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if columns.DualTable != test.lab.dualTable {
+				t.Errorf("got dual table %q, want %q", columns.DualTable, test.lab.dualTable)
+			}
+			for i, got := range columns.Columns {
+				if (got.Offset >= 0) != got.Reflected {
+					t.Errorf("column %d has offset %d, reflected %t", i, got.Offset, got.Reflected)
+				}
+				got.Offset = 0
+				if got != test.want[i] {
+					t.Errorf("got %+v, want %+v", got, test.want[i])
+				}
+			}
+			if got := columns.ReflectedColumns(); !slices.Equal(got, test.reflected) {
+				t.Errorf("got reflected columns %v, want %v", got, test.reflected)
+			}
+		})
+	}
+}
+
+func TestReflectedColumnsInPageOrder(t *testing.T) {
+	columns := ColumnMap{Columns: []ColumnProfile{
+		{Index: 0, String: true, Reflected: true, Offset: 300},
+		{Index: 1, String: true, Offset: -1},
+		{Index: 2, Integer: true, Reflected: true, Offset: 50},
+		{Index: 3, String: true, Reflected: true, Offset: 100},
+	}}
+	if got, want := columns.ReflectedColumns(), []int{3, 0}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := columns.StringColumns(), []int{0, 1, 3}; !slices.Equal(got, want) {
+		t.Errorf("got string columns %v, want %v", got, want)
+	}
+	if column, err := columns.TextColumn(); err != nil || column != 3 {
+		t.Errorf("got text column %d, %v, want 3", column, err)
+	}
+	if _, err := (ColumnMap{Columns: []ColumnProfile{{Index: 0, Integer: true}}}).TextColumn(); err == nil {
+		t.Error("want an error without a reflected string column")
+	}
+}
@@ -0,0 +1,44 @@
+package sqli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"time"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/utility"
+)
+
+// Response is a fully read HTTP response, so it can be compared after the body is closed.
+type Response struct {
+	StatusCode  int
+	Body        []byte
+	Duration    time.Duration
+	Payload     string // Injected value, stripped from the body when comparing pages
+	ContentType string
+}
+
+// isJSON reports whether the response holds JSON, by its Content-Type or else by its body.
+func (r *Response) isJSON() bool {
+	mediaType, _, _ := mime.ParseMediaType(r.ContentType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") || json.Valid(r.Body)
+}
+
+// fetchResponse sends the payload at the injection point and reads the whole response.
+func fetchResponse(client *utility.HTTPClient, point InjectionPoint, payload string) (*Response, error) {
+	response, elapsed, err := sendTimedPayload(client, point, payload)
+	if err != nil {
+		return nil, err
+	}
+	defer utility.SafeClose(response.Body)
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	// Pages usually reflect the whole value as sent, not just the appended payload
+	value, _ := point.Value(payload)
+	return &Response{StatusCode: response.StatusCode, Body: body, Duration: elapsed, Payload: value, ContentType: response.Header.Get("Content-Type")}, nil
+}
@@ -0,0 +1,179 @@
+package sqli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/utility"
+)
+
+// Extraction is a value retrieved through blind injection, complete or as far as it got.
+type Extraction struct {
+	Length int    `json:"length"` // Length of the whole value in characters
+	Value  string `json:"value"`  // Characters retrieved so far
+}
+
+// Complete reports whether every character of the value was retrieved.
+func (e Extraction) Complete() bool {
+	return utf8.RuneCountInString(e.Value) >= e.Length
+}
+
+// ValueCache remembers blind extractions so a later run does not ask for them again.
+type ValueCache interface {
+	Lookup(expression string) (Extraction, bool)
+	Store(expression string, extraction Extraction)
+}
+
+// Session caches what a scan learned about one injection point: the facts found by
+// detection and the values retrieved through blind injection. It is saved to a file
+// as it changes, so a run that stops can be resumed by the next one. It is safe
+// for concurrent use by parallel extractions.
+type Session struct {
+	path    string
+	mu      sync.Mutex
+	savedAt time.Time // Last time the file was written
+
+	Target      string                `json:"target"`
+	Boundary    *Boundary             `json:"boundary,omitempty"`
+	ColumnCount int                   `json:"column_count,omitempty"`
+	Columns     *ColumnMap            `json:"columns,omitempty"`
+	Fingerprint *Fingerprint          `json:"fingerprint,omitempty"`
+	Technique   string                `json:"technique,omitempty"` // Set when only a blind detection found the boundary
+	Values      map[string]Extraction `json:"values"`              // Keyed by the extracted SQL expression
+}
+
+// OpenSession loads the session of the injection point from dir, or starts an empty one
+// when there is none, it cannot be read or fresh is set. The file is named after the host
+// and a hash of the request shape.
+func OpenSession(dir string, point InjectionPoint, fresh bool) (*Session, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	session := &Session{
+		path:   filepath.Join(dir, sessionFileName(point)),
+		Target: point.String(),
+		Values: map[string]Extraction{},
+	}
+	if fresh {
+		return session, nil
+	}
+
+	content, err := os.ReadFile(session.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return session, nil
+	}
+	if err == nil {
+		err = json.Unmarshal(content, session)
+	}
+	if err != nil {
+		// A session only saves requests, losing it must not stop the scan
+		logger.Warningf("Ignoring unreadable session file %s, starting a fresh session: %s", session.path, err.Error())
+		fresh := &Session{path: session.path, Target: point.String(), Values: map[string]Extraction{}}
+		return fresh, nil
+	}
+	if session.Values == nil {
+		session.Values = map[string]Extraction{}
+	}
+	if session.Fingerprint != nil {
+		if err := session.Fingerprint.resolve(); err != nil {
+			logger.Warningf("Discarding the cached fingerprint: %s", err.Error())
+			session.Fingerprint = nil
+		}
+	}
+	return session, nil
+}
+
+// Path returns the file the session is saved to.
+func (s *Session) Path() string {
+	return s.path
+}
+
+// Save writes the session to its file.
+func (s *Session) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.save()
+}
+
+// save writes the session while the lock is held.
+func (s *Session) save() error {
+	if err := utility.WriteJSONFile(s.path, s); err != nil {
+		return err
+	}
+	s.savedAt = time.Now()
+	return nil
+}
+
+// Reset forgets everything the session learned, e.g. when the target has changed.
+func (s *Session) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Boundary, s.ColumnCount, s.Columns, s.Fingerprint, s.Technique = nil, 0, nil, nil, ""
+	s.Values = map[string]Extraction{}
+}
+
+// Lookup returns the cached extraction of the expression.
+func (s *Session) Lookup(expression string) (Extraction, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	extraction, found := s.Values[expression]
+	return extraction, found
+}
+
+// Store caches the extraction of the expression and saves the session at most every
+// SESSION_SAVE_SECONDS, as rewriting the file for every character would make long dumps
+// quadratic. Save writes what is left. Literals are not worth caching, e.g. the random
+// values a technique is verified with.
+func (s *Session) Store(expression string, extraction Extraction) {
+	if strings.HasPrefix(expression, "'") {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Values[expression] = extraction
+	if time.Since(s.savedAt) < constant.SESSION_SAVE_SECONDS*time.Second {
+		return
+	}
+	if err := s.save(); err != nil {
+		logger.Debugf("Failed to save session: %s", err.Error())
+	}
+}
+
+// sessionFileName names the session file after the host, which makes it easy to find, and a
+// hash of everything that shapes the requests: the point, body, cookies, extra headers and
+// tampers. Values extracted through differently shaped requests are then never mixed up.
+func sessionFileName(point InjectionPoint) string {
+	host := "target"
+	if parsedURL, err := url.Parse(point.URL); err == nil && parsedURL.Host != "" {
+		host = strings.ReplaceAll(parsedURL.Host, ":", "_")
+	}
+
+	var shape strings.Builder
+	fmt.Fprintf(&shape, "%s\n%s\n%s\n%s\n", point, point.Body, point.Cookie, point.Tampers)
+	point.Headers.Write(&shape) // Sorted by name, so the hash does not depend on flag order
+	sum := sha256.Sum256([]byte(shape.String()))
+	return host + "-" + hex.EncodeToString(sum[:8]) + ".json"
+}
+
+// resolve restores the dialect of a fingerprint read from a session, which only stores the product name.
+func (f *Fingerprint) resolve() error {
+	for _, product := range constant.Products {
+		if product.Name == f.Product {
+			f.Database = product.Database
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown product %q", f.Product)
+}
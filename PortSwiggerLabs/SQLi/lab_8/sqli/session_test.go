@@ -0,0 +1,166 @@
+package sqli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/tamper"
+)
+
+func TestSessionFileName(t *testing.T) {
+	base := func() InjectionPoint {
+		return NewInjectionPoint("GET", "https://lab.example:8443/filter?category=Gifts", LocationQuery, "category")
+	}
+	names := tamper.Names()
+	first, err := tamper.NewChain(names[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	both, err := tamper.NewChain(names[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(point *InjectionPoint)
+	}{
+		{"other parameter", func(point *InjectionPoint) { point.Name = "id" }},
+		{"other location", func(point *InjectionPoint) { point.Location = LocationCookie }},
+		{"body", func(point *InjectionPoint) { point.Body = "a=b" }},
+		{"cookie", func(point *InjectionPoint) { point.Cookie = "session=abc" }},
+		{"header", func(point *InjectionPoint) { point.Headers.Set("Authorization", "Bearer x") }},
+		{"tamper", func(point *InjectionPoint) { point.Tampers = first }},
+		{"another tamper", func(point *InjectionPoint) { point.Tampers = both }},
+	}
+	unchanged := sessionFileName(base())
+	if !strings.HasPrefix(unchanged, "lab.example_8443-") || !strings.HasSuffix(unchanged, ".json") {
+		t.Errorf("got %s, want the host in front of the hash", unchanged)
+	}
+	if again := sessionFileName(base()); again != unchanged {
+		t.Errorf("got %s and %s for the same point", unchanged, again)
+	}
+	seen := map[string]string{unchanged: "unchanged"}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			point := base()
+			test.change(&point)
+			name := sessionFileName(point)
+			if other, found := seen[name]; found {
+				t.Errorf("shares %s with %s", name, other)
+			}
+			seen[name] = test.name
+		})
+	}
+}
+
+func TestSessionFileNameHeaderOrder(t *testing.T) {
+	point := NewInjectionPoint("GET", "http://lab/", LocationQuery, "id")
+	point.Headers.Add("X-A", "1")
+	point.Headers.Add("X-B", "2")
+	reordered := NewInjectionPoint("GET", "http://lab/", LocationQuery, "id")
+	reordered.Headers.Add("X-B", "2")
+	reordered.Headers.Add("X-A", "1")
+	if sessionFileName(point) != sessionFileName(reordered) {
+		t.Error("the order headers were given in changes the file name")
+	}
+}
+
+func TestOpenSession(t *testing.T) {
+	point := NewInjectionPoint("GET", "http://lab/filter?category=Gifts", LocationQuery, "category")
+	stored := Extraction{Length: 3, Value: "abc"}
+
+	tests := []struct {
+		name    string
+		content string // Written to the session file before opening, left out when empty
+		fresh   bool
+		want    bool // Whether the stored extraction is found
+	}{
+		{"saved session", "", false, true},
+		{"fresh requested", "", true, false},
+		{"corrupt file", "{", false, false},
+		{"unknown product", `{"values":{},"fingerprint":{"product":"Nope"}}`, false, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			session, err := OpenSession(dir, point, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			session.Store("version()", stored)
+			if test.content != "" {
+				if err := os.WriteFile(session.Path(), []byte(test.content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			reopened, err := OpenSession(dir, point, test.fresh)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reopened.Path() != session.Path() || reopened.Values == nil || reopened.Fingerprint != nil {
+				t.Errorf("got %+v, want a usable session at %s", reopened, session.Path())
+			}
+			extraction, found := reopened.Lookup("version()")
+			if found != test.want || found && extraction != stored {
+				t.Errorf("got %+v, %t, want %t", extraction, found, test.want)
+			}
+		})
+	}
+}
+
+func TestSessionStoreSkipsLiterals(t *testing.T) {
+	session, err := OpenSession(t.TempDir(), NewInjectionPoint("GET", "http://lab/", LocationQuery, "id"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.Store("'qzxabc'", Extraction{Length: 6, Value: "qzxabc"})
+	if _, found := session.Lookup("'qzxabc'"); found {
+		t.Error("a literal was cached")
+	}
+	if _, err := os.Stat(session.Path()); err == nil {
+		t.Error("storing a literal saved the session")
+	}
+	if matches, _ := filepath.Glob(filepath.Join(filepath.Dir(session.Path()), ".*.tmp")); len(matches) > 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+}
+
+func TestSessionStoreBatchesWrites(t *testing.T) {
+	session, err := OpenSession(t.TempDir(), NewInjectionPoint("GET", "http://lab/", LocationQuery, "id"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := func() map[string]Extraction {
+		t.Helper()
+		reopened, err := OpenSession(filepath.Dir(session.Path()), NewInjectionPoint("GET", "http://lab/", LocationQuery, "id"), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reopened.Values
+	}
+
+	// The first value is written right away, the characters after it wait for the interval
+	session.Store("version()", Extraction{Length: 3, Value: "a"})
+	session.Store("version()", Extraction{Length: 3, Value: "ab"})
+	session.Store("user()", Extraction{Length: 1, Value: "x"})
+	if got := saved(); len(got) != 1 || got["version()"].Value != "a" {
+		t.Errorf("got %+v saved before the interval, want only the first value", got)
+	}
+	if err := session.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if got := saved(); len(got) != 2 || got["version()"].Value != "ab" {
+		t.Errorf("got %+v saved, want every stored value", got)
+	}
+	info, err := os.Stat(session.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("got mode %s, want the session readable by the owner only", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,128 @@
+package sqli
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+)
+
+var (
+	// csrfPatterns match anti-CSRF tokens in hidden inputs, meta tags and inline scripts.
+	csrfPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)(<input[^>]*name=["']?[\w-]*(?:csrf|xsrf|token|nonce)[\w-]*["']?[^>]*value=["'])[^"']*`),
+		regexp.MustCompile(`(?i)(<input[^>]*value=["'])[^"']*(["'][^>]*name=["']?[\w-]*(?:csrf|xsrf|token|nonce))`),
+		regexp.MustCompile(`(?i)(<meta[^>]*name=["'][\w-]*(?:csrf|xsrf|token)[\w-]*["'][^>]*content=["'])[^"']*`),
+		regexp.MustCompile(`(?i)((?:csrf|xsrf|nonce)[\w-]*["']?\s*[:=]\s*["'])[^"']*`),
+	}
+	// timestampPatterns match dates, times and epoch timestamps rendered into the page.
+	timestampPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\d{4}-\d{2}-\d{2}(?:[T ]\d{2}:\d{2}(?::\d{2}(?:\.\d+)?)?(?:Z|[+-]\d{2}:?\d{2})?)?`),
+		regexp.MustCompile(`\d{1,2}/\d{1,2}/\d{2,4}`),
+		regexp.MustCompile(`\d{1,2}:\d{2}:\d{2}`),
+		regexp.MustCompile(`\b1\d{9}(?:\d{3})?\b`),
+	}
+)
+
+// NormalizeBody strips content that changes between otherwise identical responses:
+// CSRF tokens, timestamps and the payloads reflected back into the page.
+func NormalizeBody(body []byte, payloads ...string) string {
+	normalized := string(body)
+	for _, payload := range payloads {
+		for _, form := range reflectedForms(payload) {
+			normalized = strings.ReplaceAll(normalized, form, "")
+		}
+	}
+	for _, pattern := range csrfPatterns {
+		normalized = pattern.ReplaceAllString(normalized, "${1}${2}")
+	}
+	for _, pattern := range timestampPatterns {
+		normalized = pattern.ReplaceAllString(normalized, "")
+	}
+	return normalized
+}
+
+// reflectedForms lists the encodings a payload is commonly reflected in.
+func reflectedForms(payload string) []string {
+	if strings.TrimSpace(payload) == "" {
+		return nil
+	}
+	return []string{
+		url.QueryEscape(payload),
+		url.PathEscape(payload),
+		strings.ReplaceAll(html.EscapeString(payload), "&#39;", "&#x27;"),
+		html.EscapeString(payload),
+		payload,
+	}
+}
+
+// Similarity returns a ratio between 0 and 1 of how alike two responses are,
+// after normalizing both and stripping the payloads each of them carried.
+func Similarity(a *Response, b *Response) float64 {
+	linesA := pageLines(NormalizeBody(a.Body, a.Payload, b.Payload))
+	linesB := pageLines(NormalizeBody(b.Body, a.Payload, b.Payload))
+	return sequenceRatio(linesA, linesB)
+}
+
+// pagesMatch reports whether two responses show the same page, tolerating dynamic fragments.
+func pagesMatch(a *Response, b *Response) bool {
+	return a.StatusCode == b.StatusCode && Similarity(a, b) >= constant.SIMILARITY_THRESHOLD
+}
+
+// pageLines splits a page into trimmed, non-empty lines. Tags are put on their own
+// lines so minified pages still diff at a useful granularity.
+func pageLines(page string) []string {
+	page = strings.ReplaceAll(page, ">", ">\n")
+	var lines []string
+	for _, line := range strings.Split(page, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// sequenceRatio computes 2*M/T where M is the length of the longest common
+// subsequence and T the total number of lines, like Python's difflib ratio.
+// Very large pages fall back to comparing line counts, which ignores order.
+func sequenceRatio(a []string, b []string) float64 {
+	total := len(a) + len(b)
+	if total == 0 {
+		return 1
+	}
+	if len(a)*len(b) > constant.MAX_DIFF_CELLS {
+		return 2 * float64(commonLines(a, b)) / float64(total)
+	}
+
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				current[j+1] = previous[j] + 1
+			} else {
+				current[j+1] = max(previous[j+1], current[j])
+			}
+		}
+		previous, current = current, previous
+	}
+	return 2 * float64(previous[len(b)]) / float64(total)
+}
+
+// commonLines counts the lines both pages share, regardless of their order.
+func commonLines(a []string, b []string) int {
+	counts := make(map[string]int, len(a))
+	for _, line := range a {
+		counts[line]++
+	}
+	common := 0
+	for _, line := range b {
+		if counts[line] > 0 {
+			counts[line]--
+			common++
+		}
+	}
+	return common
+}
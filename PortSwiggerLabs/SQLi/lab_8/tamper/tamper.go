@@ -0,0 +1,102 @@
+package tamper
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Tamper rewrites a payload to slip past input filters and WAFs.
+// Tampers are chained, each one receives the output of the previous one.
+type Tamper interface {
+	Name() string
+	Description() string
+	Apply(payload string) string
+}
+
+// Func adapts a plain function to the Tamper interface, so custom tampers can be
+// registered without declaring a type:
+//
+//	tamper.Register(tamper.Func{TamperName: "lowercase", Summary: "Lower-cases the payload", Fn: strings.ToLower})
+type Func struct {
+	TamperName string
+	Summary    string
+	Fn         func(payload string) string
+}
+
+func (f Func) Name() string {
+	return f.TamperName
+}
+
+func (f Func) Description() string {
+	return f.Summary
+}
+
+func (f Func) Apply(payload string) string {
+	return f.Fn(payload)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Tamper{}
+)
+
+// Register makes a tamper selectable by name. Registering a name twice replaces the first tamper.
+func Register(tamper Tamper) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[strings.ToLower(tamper.Name())] = tamper
+}
+
+// Get returns the tamper registered under the name.
+func Get(name string) (Tamper, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	tamper, ok := registry[strings.ToLower(name)]
+	return tamper, ok
+}
+
+// Names lists the registered tampers in alphabetical order.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Chain applies tampers in order. The zero value leaves payloads unchanged.
+type Chain []Tamper
+
+// NewChain looks up the named tampers in the order given.
+func NewChain(names []string) (Chain, error) {
+	chain := make(Chain, 0, len(names))
+	for _, name := range names {
+		tamper, ok := Get(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown tamper %q, expected one of %v", name, Names())
+		}
+		chain = append(chain, tamper)
+	}
+	return chain, nil
+}
+
+// Apply runs the payload through every tamper of the chain.
+func (c Chain) Apply(payload string) string {
+	for _, tamper := range c {
+		payload = tamper.Apply(payload)
+	}
+	return payload
+}
+
+func (c Chain) String() string {
+	names := make([]string, len(c))
+	for i, tamper := range c {
+		names[i] = tamper.Name()
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,53 @@
+package tamper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewChain(t *testing.T) {
+	tests := []struct {
+		name    string
+		names   []string
+		payload string
+		want    string
+		wantErr bool
+	}{
+		{"empty", nil, "' OR 1=1", "' OR 1=1", false},
+		{"applied in order", []string{"space2comment", "doubleurlencode"}, "' OR 1", "%27%2F%2A%2A%2FOR%2F%2A%2A%2F1", false},
+		{"other order", []string{"doubleurlencode", "space2comment"}, "' OR 1", "%27%20OR%201", false},
+		{"names ignore case", []string{"Space2Comment"}, "' OR 1", "'/**/OR/**/1", false},
+		{"unknown", []string{"space2comment", "nope"}, "", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chain, err := NewChain(test.names)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := chain.Apply(test.payload); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register(Func{TamperName: "Test-Upper", Summary: "Upper-cases the payload", Fn: strings.ToUpper})
+	tamper, ok := Get("test-upper")
+	if !ok {
+		t.Fatal("registered tamper not found")
+	}
+	if got := tamper.Apply("' or 1=1"); got != "' OR 1=1" {
+		t.Errorf("got %q", got)
+	}
+	if names := Names(); !strings.Contains(strings.Join(names, ","), "test-upper") {
+		t.Errorf("Names() = %v, want it to list test-upper", names)
+	}
+}
@@ -3,8 +3,9 @@ package utility
 import (
 	"errors"
 	"flag"
+	"strings"
 
-	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_7/logger"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
 )
 
 // Config holds application configuration parsed from command-line flags.
@@ -12,8 +13,79 @@ type Config struct {
 	LabURL   string
 	ProxyURL string
 	LogLevel string
+
+	// Blind requests sent in parallel, and the request rate shared by all of them
+	Threads int
+	Rate    float64
+
+	// Whole values tried before blind bisection, the built-in list plus the words of DictionaryFile
+	Dictionary     bool
+	DictionaryFile string
+
+	// Injection point
+	Method    string
+	Location  string
+	Parameter string
+	Data      string
+	Cookie    string
+	Headers   HeaderFlags
+
+	// Comma-separated tampers applied to every payload
+	Tamper string
+
+	// Oracle that decides whether a response is true, e.g. status:200 or regex:Welcome back
+	Oracle string
+	// DBMS names the database product, which skips fingerprinting, e.g. MySQL; empty to detect it
+	DBMS string
+
+	// Dump mode
+	Dump          bool
+	Enumerate     bool // Report the current user, database, hostname, accounts and DBA status
+	Technique     string
+	Schemas       string
+	Tables        string
+	Columns       string
+	RowLimit      int
+	Output        string
+	IncludeSystem bool
+	Aggregate     bool
+
+	// Session file that caches detection results and extracted values across runs
+	SessionDir string
+	Fresh      bool
+
+	// Local mirror of dumped tables, disabled when LootDir is empty
+	LootDir    string
+	LootFormat string
+
+	// Interactive SQL shell
+	Shell bool
+
+	// Stacked queries, disabled unless Stacked is set
+	Stacked     bool
+	SQL         string
+	AllowWrites bool
+
+	// OAST collector used by the oob technique
+	OASTDomain string
+	OASTDNS    string
+	OASTHTTP   string
 }
 
+// HeaderFlags collects repeated -H "Name: value" flags.
+type HeaderFlags []string
+
+func (h *HeaderFlags) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *HeaderFlags) Set(value string) error {
+	if !strings.Contains(value, ":") {
+		return errors.New("header must be in the form \"Name: value\"")
+	}
+	*h = append(*h, value)
+	return nil
+}
 
 // parseArgs parses command-line arguments and returns Config or an error.
 func ParseArgs() (Config, error) {
@@ -22,6 +94,40 @@ func ParseArgs() (Config, error) {
 	flag.StringVar(&config.LabURL, "u", "", "Target URL of the PortSwigger Lab (required)")
 	flag.StringVar(&config.ProxyURL, "proxy", "", "Optional proxy URL (e.g., http://127.0.0.1:8080)")
 	flag.StringVar(&config.LogLevel, "log-level", "info", "Set log level (debug, info, action, warning, fatal, success)")
+	flag.IntVar(&config.Threads, "threads", 1, "Blind extraction requests sent in parallel, raise it to opt in to parallelism (time-based extraction always uses 1)")
+	flag.Float64Var(&config.Rate, "rate", 0, "Maximum requests per second, 0 for no limit")
+	flag.BoolVar(&config.Dictionary, "dictionary", false, "Try common identifiers as whole values before bisecting blind values")
+	flag.StringVar(&config.DictionaryFile, "dictionary-file", "", "Extra words for the dictionary pass, one per line (implies -dictionary)")
+	flag.StringVar(&config.Method, "X", "GET", "HTTP method of the injected request")
+	flag.StringVar(&config.Location, "location", "query", "Where the payload is injected (query, form, cookie, header, json, xml)")
+	flag.StringVar(&config.Parameter, "p", "category", "Parameter, cookie or header name, JSON path (e.g. user.id) or XML element to inject into")
+	flag.StringVar(&config.Data, "data", "", "Request body for form, JSON or XML injection points")
+	flag.StringVar(&config.Cookie, "cookie", "", "Cookie header to send (e.g., \"TrackingId=abc; session=xyz\")")
+	flag.Var(&config.Headers, "H", "Extra header in the form \"Name: value\" (repeatable)")
+	flag.StringVar(&config.Tamper, "tamper", "", "Comma-separated tampers applied to every payload, in order (e.g. space2comment,randomcase)")
+	flag.StringVar(&config.Oracle, "oracle", "auto", "Response oracle: auto, status[:code], length, hash, regex:<pattern>, contains:<text>, selector:<css>, time[:threshold]")
+	flag.StringVar(&config.DBMS, "dbms", "", "Database product behind the target (Oracle, MSSQL, MySQL, MariaDB, PostgreSQL, CockroachDB, SQLite), skips fingerprinting")
+	flag.StringVar(&config.SessionDir, "session-dir", "", "Directory of the session files that runs resume from (default ~/.sqli_sessions)")
+	flag.BoolVar(&config.Fresh, "fresh", false, "Ignore the saved session and scan the target from scratch")
+	flag.BoolVar(&config.Dump, "dump", false, "Dump every schema, table and row instead of only the administrator password")
+	flag.BoolVar(&config.Enumerate, "enum", false, "Enumerate the current user, database, hostname, DB users, password hashes and DBA status, alone or with -dump")
+	flag.StringVar(&config.Technique, "technique", "auto", "Technique used to dump and by the shell (auto, union, boolean, time, error, conditional, oob)")
+	flag.StringVar(&config.Schemas, "schemas", "", "Comma-separated schemas to dump (default all)")
+	flag.StringVar(&config.Tables, "tables", "", "Comma-separated tables to dump (default all)")
+	flag.StringVar(&config.Columns, "columns", "", "Comma-separated columns to dump (default all)")
+	flag.IntVar(&config.RowLimit, "limit", 0, "Maximum rows dumped per table, 0 for no limit")
+	flag.StringVar(&config.Output, "output", "dump.json", "File the dump is written to as JSON")
+	flag.BoolVar(&config.IncludeSystem, "system", false, "Also dump built-in schemas such as information_schema")
+	flag.BoolVar(&config.Aggregate, "aggregate", true, "Retrieve lists in one request with string_agg, group_concat or listagg when possible")
+	flag.StringVar(&config.LootDir, "loot", "", "Directory every dumped table is mirrored to, rows already there are not retrieved again")
+	flag.StringVar(&config.LootFormat, "loot-format", "csv,sql", "Comma-separated formats of the mirror (csv, sql)")
+	flag.BoolVar(&config.Shell, "shell", false, "Open an interactive SQL shell once the injection is set up")
+	flag.BoolVar(&config.Stacked, "stacked", false, "Detect stacked query support and run -sql through it")
+	flag.StringVar(&config.SQL, "sql", "", "Statement run as a stacked query, requires -stacked")
+	flag.BoolVar(&config.AllowWrites, "allow-writes", false, "Allow -sql statements that modify data or schema (INSERT, UPDATE, DROP, ...)")
+	flag.StringVar(&config.OASTDomain, "oast-domain", "oast.local", "Base domain of the OAST collector used by the oob technique")
+	flag.StringVar(&config.OASTDNS, "oast-dns", ":53", "UDP listen address of the OAST collector's DNS listener")
+	flag.StringVar(&config.OASTHTTP, "oast-http", "", "TCP listen address of the OAST collector's HTTP listener, empty to disable")
 	flag.Parse() // Parse flags defined above
 
 	if config.LabURL == "" {
@@ -29,5 +135,11 @@ func ParseArgs() (Config, error) {
 		flag.PrintDefaults() // Print default usage information
 		return config, errors.New("missing target URL")
 	}
+	if config.Threads < 1 {
+		return config, errors.New("-threads must be at least 1")
+	}
+	if config.SQL != "" && !config.Stacked {
+		return config, errors.New("-sql runs stacked queries, which are disabled without -stacked")
+	}
 	return config, nil
-}
\ No newline at end of file
+}
@@ -4,15 +4,17 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 
-	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_7/logger"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
 )
 
-type HTTPClient struct{
-	client    *http.Client
+type HTTPClient struct {
+	client  *http.Client
+	limiter *RateLimiter // Shared by every goroutine sending through the client
 }
 
-func NewClient(proxyURL string) (*HTTPClient, error){
+func NewClient(proxyURL string) (*HTTPClient, error) {
 	transport := &http.Transport{}
 
 	if proxyURL != "" {
@@ -30,19 +32,42 @@ func NewClient(proxyURL string) (*HTTPClient, error){
 	return &HTTPClient{client: &http.Client{Transport: transport}}, nil
 }
 
+// SetRateLimit limits the client to the given requests per second, 0 for no limit.
+func (httpClient *HTTPClient) SetRateLimit(rate float64) {
+	httpClient.limiter = NewRateLimiter(rate)
+}
+
 // sendRequest sends a GET request to the specified URL (including payload).
 func (httpClient *HTTPClient) SendGetRequest(fullURL string) (*http.Response, error) {
-	logger.Infof("Sending request to: %s", fullURL)
+	resp, _, err := httpClient.SendTimedGetRequest(fullURL)
+	return resp, err
+}
 
+// SendTimedGetRequest sends a GET request and also returns the time it took
+// for the response headers to arrive.
+func (httpClient *HTTPClient) SendTimedGetRequest(fullURL string) (*http.Response, time.Duration, error) {
 	req, err := http.NewRequest(http.MethodGet, fullURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request for %s: %w", fullURL, err)
+		return nil, 0, fmt.Errorf("failed to create request for %s: %w", fullURL, err)
 	}
+	return httpClient.SendRequest(req)
+}
+
+// SendRequest sends a prepared request and returns the time it took for the
+// response headers to arrive.
+func (httpClient *HTTPClient) SendRequest(req *http.Request) (*http.Response, time.Duration, error) {
+	fullURL := req.URL.String()
+	httpClient.limiter.Wait()
+	logger.Infof("Sending %s request to: %s", req.Method, fullURL)
 
+	// Waiting for the rate limiter is not part of the measured time
+	start := time.Now()
 	resp, err := httpClient.client.Do(req)
+	elapsed := time.Since(start)
 	if err != nil {
-		return nil, fmt.Errorf("http request failed for %s: %w", fullURL, err)
+		return nil, elapsed, fmt.Errorf("http request failed for %s: %w", fullURL, err)
 	}
+	logger.Debugf("Response received in %s", elapsed)
 
-	return resp, nil
-}
\ No newline at end of file
+	return resp, elapsed, nil
+}
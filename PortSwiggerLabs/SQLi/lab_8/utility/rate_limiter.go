@@ -0,0 +1,41 @@
+package utility
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter spaces requests evenly so no more than the configured number are
+// sent per second, however many goroutines share it.
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time // Earliest time the next request may be sent
+}
+
+// NewRateLimiter returns a limiter for the given requests per second, or nil, which
+// never waits, when rate is not positive.
+func NewRateLimiter(rate float64) *RateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &RateLimiter{interval: time.Duration(float64(time.Second) / rate)}
+}
+
+// Wait blocks until the caller may send its request.
+func (l *RateLimiter) Wait() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	time.Sleep(wait)
+}
@@ -0,0 +1,55 @@
+package utility
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	tests := []struct {
+		name     string
+		rate     float64
+		requests int
+		workers  int
+		minimum  time.Duration // Least time the requests may take
+		maximum  time.Duration
+	}{
+		{"no limit", 0, 50, 5, 0, 50 * time.Millisecond},
+		{"negative rate", -1, 50, 5, 0, 50 * time.Millisecond},
+		{"one worker", 100, 11, 1, 100 * time.Millisecond, 400 * time.Millisecond},
+		{"shared by workers", 100, 11, 4, 100 * time.Millisecond, 400 * time.Millisecond},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			limiter := NewRateLimiter(test.rate)
+			if (limiter == nil) != (test.rate <= 0) {
+				t.Fatalf("got limiter %v for rate %v", limiter, test.rate)
+			}
+
+			jobs := make(chan struct{}, test.requests)
+			for range test.requests {
+				jobs <- struct{}{}
+			}
+			close(jobs)
+			start := time.Now()
+			var wg sync.WaitGroup
+			for range test.workers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for range jobs {
+						limiter.Wait()
+					}
+				}()
+			}
+			wg.Wait()
+
+			// The first request goes out at once, every later one waits an interval
+			elapsed := time.Since(start)
+			if elapsed < test.minimum || elapsed > test.maximum {
+				t.Errorf("%d requests took %s, want between %s and %s", test.requests, elapsed, test.minimum, test.maximum)
+			}
+		})
+	}
+}
@@ -1,10 +1,15 @@
 package utility
 
 import (
+	"bufio"
+	"encoding/json"
 	"io"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 
-	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_7/logger"
+	"github.io/kinasr/pen_payloads/PortSwiggerLabs/SQLi/lab_8/logger"
 )
 
 // normalizeURL ensures the URL has a scheme and no trailing slash.
@@ -20,6 +25,15 @@ func NormalizeURL(rawURL string) string {
 	return url
 }
 
+// WithDefaultPath appends the default path when the URL only names a host.
+func WithDefaultPath(rawURL string, defaultPath string) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || (parsedURL.Path == "" && parsedURL.RawQuery == "") {
+		return rawURL + defaultPath
+	}
+	return rawURL
+}
+
 // safeClose attempts to close an io.Closer and logs any error.
 func SafeClose(closer io.Closer) {
 	if closer == nil {
@@ -30,7 +44,73 @@ func SafeClose(closer io.Closer) {
 	}
 }
 
-// URLEncode encodes a string for use in a URL query.
-func URLEncode(s string) string {
-	return strings.ReplaceAll(strings.ReplaceAll(s, " ", "+"), "'", "%27")
+// SplitList splits a comma-separated flag value, dropping empty entries.
+func SplitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// WriteJSONFile writes the value to a file as indented JSON, readable by the owner only as it
+// may hold retrieved data. The file is replaced atomically, so a run interrupted while writing
+// leaves the previous content rather than a truncated file.
+func WriteJSONFile(path string, value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name()) // Fails harmlessly once the file has been renamed
+	if _, err := temp.Write(append(data, '\n')); err != nil {
+		SafeClose(temp)
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temp.Name(), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+// CreatePrivateFile creates or truncates a file readable by the owner only, also when it
+// already existed with a wider mode.
+func CreatePrivateFile(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Chmod(0o600); err != nil {
+		SafeClose(file)
+		return nil, err
+	}
+	return file, nil
+}
+
+// ReadWordlist reads one word per line, skipping blank lines and # comments.
+func ReadWordlist(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer SafeClose(file)
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			words = append(words, line)
+		}
+	}
+	return words, scanner.Err()
 }
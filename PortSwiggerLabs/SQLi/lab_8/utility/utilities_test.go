@@ -0,0 +1,161 @@
+package utility
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestWriteJSONFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous string // Content of the file before writing, no file when empty
+		value    any
+		want     string
+		wantErr  bool
+	}{
+		{"new file", "", map[string]int{"a": 1}, "{\n  \"a\": 1\n}\n", false},
+		{"replaces content", `{"old":true,"longer":"than the new content"}`, []string{"x"}, "[\n  \"x\"\n]\n", false},
+		{"unencodable value keeps the old file", "old", func() {}, "old", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "session.json")
+			if test.previous != "" {
+				if err := os.WriteFile(path, []byte(test.previous), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := WriteJSONFile(path, test.value)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want an error: %t", err, test.wantErr)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != test.want {
+				t.Errorf("got %q, want %q", content, test.want)
+			}
+			if info, err := os.Stat(path); err == nil && !test.wantErr && info.Mode().Perm() != 0o600 {
+				t.Errorf("got mode %s, want the file readable by the owner only", info.Mode().Perm())
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("got %d files, want no temporary file left", len(entries))
+			}
+		})
+	}
+}
+
+func TestWriteJSONFileMissingDir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "session.json")
+	if err := WriteJSONFile(path, 1); err == nil {
+		t.Error("want an error")
+	}
+}
+
+func TestCreatePrivateFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous bool // Whether a world-readable file exists before
+	}{
+		{"new file", false},
+		{"existing file", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "loot.csv")
+			if test.previous {
+				if err := os.WriteFile(path, []byte("old content"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			file, err := CreatePrivateFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			SafeClose(file)
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != 0o600 || info.Size() != 0 {
+				t.Errorf("got mode %s and %d bytes, want an empty file readable by the owner only", info.Mode().Perm(), info.Size())
+			}
+		})
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"", nil},
+		{"csv", []string{"csv"}},
+		{"csv, json ,sql", []string{"csv", "json", "sql"}},
+		{",a,,b,", []string{"a", "b"}},
+	}
+	for _, test := range tests {
+		if got := SplitList(test.value); !slices.Equal(got, test.want) {
+			t.Errorf("SplitList(%q) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
+
+func TestWithDefaultPath(t *testing.T) {
+	tests := []struct {
+		rawURL string
+		want   string
+	}{
+		{"https://lab.net", "https://lab.net/filter?category=Gifts"},
+		{"https://lab.net/other", "https://lab.net/other"},
+		{"https://lab.net?id=1", "https://lab.net?id=1"},
+	}
+	for _, test := range tests {
+		if got := WithDefaultPath(test.rawURL, "/filter?category=Gifts"); got != test.want {
+			t.Errorf("WithDefaultPath(%q) = %q, want %q", test.rawURL, got, test.want)
+		}
+	}
+}
+
+func TestReadWordlist(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"one word per line", "admin\nadministrator\n", []string{"admin", "administrator"}},
+		{"comments and blank lines skipped", "# users\n\nadmin\n  \n#carlos\n", []string{"admin"}},
+		{"surrounding spaces trimmed", "  wiener \r\n\tcarlos", []string{"wiener", "carlos"}},
+		{"empty", "", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "words.txt")
+			if err := os.WriteFile(path, []byte(test.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			words, err := ReadWordlist(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(words, test.want) {
+				t.Errorf("got %q, want %q", words, test.want)
+			}
+		})
+	}
+}
+
+func TestReadWordlistMissingFile(t *testing.T) {
+	if _, err := ReadWordlist(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("want an error for a missing wordlist")
+	}
+}